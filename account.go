@@ -1,12 +1,11 @@
 package main
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
-	"net/http"
 	"strings"
 
+	"github.com/TinyHatchet/client/tinyhatchet"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 )
@@ -87,8 +86,10 @@ type titledInput struct {
 }
 
 type changeEmailForm struct {
-	focusIndex int
-	inputs     []titledInput
+	focusIndex  int
+	inputs      []titledInput
+	emailErrors []string
+	Error       error
 }
 
 func changeEmail() changeEmailForm {
@@ -130,6 +131,7 @@ func (form changeEmailForm) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "tab", "shift+tab", "enter", "up", "down":
 			s := msg.String()
 			if s == "enter" && form.focusIndex == len(form.inputs) {
+				form.emailErrors, form.Error = nil, nil
 				return form, form.updateEmail
 			}
 			if s == "up" || s == "shift+tab" {
@@ -160,6 +162,18 @@ func (form changeEmailForm) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 	case success:
 		return account(), nil
+	case *tinyhatchet.APIError:
+		if tinyhatchet.IsUnauthorized(msg) {
+			return sessionExpired()
+		}
+		form.emailErrors = msg.FieldErrors("email")
+		if form.emailErrors == nil {
+			form.Error = msg
+		}
+		return form, nil
+	case error:
+		form.Error = msg
+		return form, nil
 
 	case tea.WindowSizeMsg:
 		width, height = msg.Width, msg.Height
@@ -190,6 +204,12 @@ func (form changeEmailForm) View() string {
 			b.WriteRune('\n')
 		}
 	}
+	for _, err := range form.emailErrors {
+		fmt.Fprintf(&b, "\n          %s", errorStyle.Render(err))
+	}
+	if form.Error != nil {
+		fmt.Fprintf(&b, "\n\n%s", errorStyle.Render(strings.Title(form.Error.Error())))
+	}
 
 	buttonStyle := &blurredStyle
 	if form.focusIndex == len(form.inputs) {
@@ -200,27 +220,10 @@ func (form changeEmailForm) View() string {
 	return b.String()
 }
 
-type changeEmailCommand struct {
-	Email string `json:"email"`
-}
-
 func (form changeEmailForm) updateEmail() tea.Msg {
-	cmd := changeEmailCommand{
-		Email: form.inputs[0].Value(),
-	}
-	buf := bytes.NewBuffer(nil)
-	encoder := json.NewEncoder(buf)
-	err := encoder.Encode(cmd)
-	if err != nil {
-		return err
-	}
-	resp, err := httpClient.Post(appConfig.BuildURL("/account/change_email"), contentTypeJSON, buf)
+	err := client.UpdateEmail(context.Background(), form.inputs[0].Value())
 	if err != nil {
 		return err
 	}
-	if resp.StatusCode == http.StatusOK {
-		return success{}
-	}
-	//TODO: Handle Errors
-	return nil
+	return success{}
 }
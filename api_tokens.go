@@ -1,17 +1,18 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"net/http"
 	"strings"
 
+	"github.com/TinyHatchet/client/tinyhatchet"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
 type apiTokenMenu struct {
 	cursor  int
 	choices []interface{}
+	Error   error
 }
 
 type deletedID string
@@ -46,22 +47,24 @@ func (m apiTokenMenu) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		case "enter", " ":
 			if m.cursor == len(m.choices)-1 {
+				m.Error = nil
 				return m, m.createToken
 			}
 		case "ctrl+d":
 			if m.cursor < len(m.choices)-1 {
-				token, ok := m.choices[m.cursor].(apiToken)
+				token, ok := m.choices[m.cursor].(tinyhatchet.Token)
 				if !ok {
 					return m, nil
 				}
+				m.Error = nil
 				return m, m.deleteToken(token)
 			}
 		}
-	case apiToken:
+	case tinyhatchet.Token:
 		m.choices[len(m.choices)-1] = msg
 		m.choices = append(m.choices, createNewTokenText)
 		return m, nil
-	case []apiToken:
+	case []tinyhatchet.Token:
 		if len(msg) == 0 {
 			return m, nil
 		}
@@ -75,13 +78,22 @@ func (m apiTokenMenu) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case deletedID:
 		newChoices := make([]interface{}, 0, len(m.choices)-1)
 		for _, choice := range m.choices {
-			token, ok := choice.(apiToken)
+			token, ok := choice.(tinyhatchet.Token)
 			if ok && token.ID == string(msg) {
 				continue
 			}
 			newChoices = append(newChoices, choice)
 		}
 		m.choices = newChoices
+		if m.cursor > len(m.choices)-1 {
+			m.cursor = len(m.choices) - 1
+		}
+		return m, nil
+	case error:
+		if tinyhatchet.IsUnauthorized(msg) {
+			return sessionExpired()
+		}
+		m.Error = msg
 		return m, nil
 
 	case tea.WindowSizeMsg:
@@ -102,7 +114,7 @@ func (m apiTokenMenu) View() string {
 			cursor = ">"
 		}
 		switch choice := choice.(type) {
-		case apiToken:
+		case tinyhatchet.Token:
 			fmt.Fprintf(b, "%s ID: %s\n", cursor, choice.ID)
 			if choice.Secret != "" {
 				fmt.Fprintf(b, "\tSecret: %s\n", choice.Secret)
@@ -114,64 +126,38 @@ func (m apiTokenMenu) View() string {
 
 	}
 
+	if m.Error != nil {
+		fmt.Fprintf(b, "\n%s\n", errorStyle.Render(strings.Title(m.Error.Error())))
+	}
+
 	fmt.Fprint(b, "\nPress ctrl+d to delete a token.")
 	fmt.Fprint(b, "\nPress q to quit.\n")
 
 	return b.String()
 }
 
-type apiToken struct {
-	ID     string `json:"id"`
-	Secret string `json:"secret"`
-}
-
 func (m apiTokenMenu) createToken() tea.Msg {
-	resp, err := httpClient.Post(appConfig.BuildURL("/auth/api_token"), contentTypeJSON, nil)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-	decoder := json.NewDecoder(resp.Body)
-	token := apiToken{}
-	err = decoder.Decode(&token)
+	token, err := client.CreateToken(context.Background())
 	if err != nil {
 		return err
 	}
 	return token
 }
 
-type listTokensResponse struct {
-	Tokens []apiToken `json:"tokens"`
-}
-
 func (m apiTokenMenu) listTokens() tea.Msg {
-	resp, err := httpClient.Get(appConfig.BuildURL("/auth/api_token"))
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-	decoder := json.NewDecoder(resp.Body)
-	listResponse := listTokensResponse{}
-	err = decoder.Decode(&listResponse)
+	tokens, err := client.ListTokens(context.Background())
 	if err != nil {
 		return err
 	}
-	return listResponse.Tokens
+	return tokens
 }
 
-func (m apiTokenMenu) deleteToken(token apiToken) tea.Cmd {
+func (m apiTokenMenu) deleteToken(token tinyhatchet.Token) tea.Cmd {
 	return func() tea.Msg {
-		req, err := http.NewRequest(http.MethodDelete, fmt.Sprintf(appConfig.BuildURL("/auth/api_token?id=%s"), token.ID), nil)
+		err := client.DeleteToken(context.Background(), token.ID)
 		if err != nil {
 			return err
 		}
-		resp, err := httpClient.Do(req)
-		if err != nil {
-			return err
-		}
-		if resp.StatusCode != 200 {
-			return nil
-		}
 		return deletedID(token.ID)
 	}
 }
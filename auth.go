@@ -0,0 +1,23 @@
+package main
+
+import (
+	"github.com/TinyHatchet/client/tinyhatchet"
+)
+
+const (
+	envTokenID     = "TINYHATCHET_TOKEN_ID"
+	envTokenSecret = "TINYHATCHET_TOKEN_SECRET"
+)
+
+// appToken is the API token attached to every request, empty when the
+// client relies on the session cookie from an interactive login
+var appToken tinyhatchet.Token
+
+// resolveToken uses override when it is set, then the profile.
+// The id and secret are always taken from the same source.
+func resolveToken(override tinyhatchet.Token, profile Profile) tinyhatchet.Token {
+	if override.ID != "" || override.Secret != "" {
+		return override
+	}
+	return tinyhatchet.Token{ID: profile.APITokenID, Secret: profile.APITokenSecret}
+}
@@ -0,0 +1,508 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/TinyHatchet/client/tinyhatchet"
+)
+
+const (
+	// cacheSettle is how long before a search its range counts as complete,
+	// entries can reach the server a little after their timestamp
+	cacheSettle    = time.Minute
+	cacheIndexFile = "index.json"
+	// cacheMaxAge is how long entries are kept, counted from their timestamp
+	cacheMaxAge = 30 * 24 * time.Hour
+	// cacheMaxEntries is how many entries a search file keeps, the oldest are dropped first
+	cacheMaxEntries = 100000
+	// cacheMaxFiles is how many search files are kept, the least recently used are removed first
+	cacheMaxFiles = 50
+	// cacheCompactSlack is how many lines a file can grow by before it is compacted again
+	cacheCompactSlack = 5000
+)
+
+var (
+	// resultCache stores the entries fetched for the current profile, nil when caching is off
+	resultCache *searchCache
+	// offlineMode answers searches from the cache without contacting the server
+	offlineMode bool
+)
+
+var errCacheDisabled = errors.New("the cache is disabled, there is nothing to search offline")
+
+type timeRange struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// cacheFile describes the file holding the entries of one combination of tags and text
+type cacheFile struct {
+	// Ranges are the time ranges the file holds every matching entry for
+	Ranges []timeRange `json:"ranges"`
+	// Lines counts the entries in the file, duplicates included,
+	// Kept those left by the last compaction
+	Lines int       `json:"lines"`
+	Kept  int       `json:"kept"`
+	Used  time.Time `json:"used"`
+}
+
+// searchCache keeps the entries fetched for every combination of tags and text
+// in a JSONL file of their own, appended to as results arrive. The index records
+// the time ranges each file holds every matching entry for, so that a search
+// over a range that was fetched before only asks the server for what is missing.
+//
+// Appending can leave the same entry in a file more than once, a file is
+// compacted once it grows past twice what its last compaction kept, dropping
+// the duplicates, the entries older than cacheMaxAge and the oldest ones over
+// cacheMaxEntries.
+type searchCache struct {
+	mu    sync.Mutex
+	dir   string
+	files map[string]*cacheFile
+}
+
+// cacheDirectory places the cache of a profile under the user cache directory,
+// keyed by the server too so that pointing a profile elsewhere starts afresh
+func cacheDirectory(serverURL, profile string) (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(serverURL))
+	return filepath.Join(dir, "tinyhatchet", fmt.Sprintf("%s-%x", profile, sum[:6])), nil
+}
+
+// openCache opens the cache of the profile, logging why when it cannot be used
+func openCache(serverURL, profile string) *searchCache {
+	dir, err := cacheDirectory(serverURL, profile)
+	if err != nil {
+		log.Println(fmt.Errorf("find cache directory: %w", err))
+		return nil
+	}
+	c := &searchCache{dir: dir, files: map[string]*cacheFile{}}
+	body, err := ioutil.ReadFile(filepath.Join(dir, cacheIndexFile))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Println(fmt.Errorf("read cache index: %w", err))
+		}
+		return c
+	}
+	err = json.Unmarshal(body, &c.files)
+	if err != nil {
+		// without the index the files cannot be trusted, start over
+		log.Println(fmt.Errorf("unmarshal cache index: %w", err))
+		err = c.clear()
+		if err != nil {
+			log.Println(err)
+		}
+	}
+	return c
+}
+
+// clearCache removes the cached entries of the profile, whether or not caching is on
+func clearCache(serverURL, profile string) error {
+	if resultCache != nil {
+		return resultCache.clear()
+	}
+	dir, err := cacheDirectory(serverURL, profile)
+	if err != nil {
+		return fmt.Errorf("find cache directory: %w", err)
+	}
+	err = os.RemoveAll(dir)
+	if err != nil {
+		return fmt.Errorf("remove cache: %w", err)
+	}
+	return nil
+}
+
+// clear removes every cached entry
+func (c *searchCache) clear() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.files = map[string]*cacheFile{}
+	err := os.RemoveAll(c.dir)
+	if err != nil {
+		return fmt.Errorf("remove cache: %w", err)
+	}
+	return nil
+}
+
+// cacheKey identifies the entries a query matches apart from its time range
+func cacheKey(query tinyhatchet.Query) string {
+	return query.Tags + "\x00" + query.Text
+}
+
+func (c *searchCache) entriesPath(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.dir, fmt.Sprintf("%x.jsonl", sum[:8]))
+}
+
+// queryRange is the range the query covers at now, an empty start reaches back to the zero time
+func queryRange(query tinyhatchet.Query, now time.Time) timeRange {
+	r := timeRange{End: now}
+	if query.Start != "" {
+		r.Start, _ = time.Parse(time.RFC3339Nano, query.Start)
+	}
+	if query.End != "" {
+		r.End, _ = time.Parse(time.RFC3339Nano, query.End)
+	}
+	return r
+}
+
+// missing returns the parts of the query's range the cache does not hold
+func (c *searchCache) missing(query tinyhatchet.Query, now time.Time) []timeRange {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var covered []timeRange
+	if f, ok := c.files[cacheKey(query)]; ok {
+		covered = f.Ranges
+	}
+	return subtractRanges(queryRange(query, now), covered)
+}
+
+// holdsAny reports whether the cache holds any part of the query's range
+func (c *searchCache) holdsAny(query tinyhatchet.Query, now time.Time) bool {
+	r := queryRange(query, now)
+	gaps := c.missing(query, now)
+	return len(gaps) != 1 || !gaps[0].Start.Equal(r.Start) || !gaps[0].End.Equal(r.End)
+}
+
+// subtractRanges returns the parts of r outside of covered, which is sorted and merged
+func subtractRanges(r timeRange, covered []timeRange) []timeRange {
+	gaps := []timeRange{}
+	start := r.Start
+	for _, c := range covered {
+		if !c.End.After(start) {
+			continue
+		}
+		if !c.Start.Before(r.End) {
+			break
+		}
+		if c.Start.After(start) {
+			gaps = append(gaps, timeRange{Start: start, End: c.Start})
+		}
+		start = c.End
+	}
+	if start.Before(r.End) {
+		gaps = append(gaps, timeRange{Start: start, End: r.End})
+	}
+	return gaps
+}
+
+// addRange adds r to the sorted and merged ranges, merging it with those it touches
+func addRange(ranges []timeRange, r timeRange) []timeRange {
+	all := append(append([]timeRange{}, ranges...), r)
+	sort.Slice(all, func(i, j int) bool { return all[i].Start.Before(all[j].Start) })
+	merged := all[:1]
+	for _, next := range all[1:] {
+		last := &merged[len(merged)-1]
+		if next.Start.After(last.End) {
+			merged = append(merged, next)
+			continue
+		}
+		if next.End.After(last.End) {
+			last.End = next.End
+		}
+	}
+	return merged
+}
+
+// clipRanges returns the parts of the sorted and merged ranges from start on
+func clipRanges(ranges []timeRange, start time.Time) []timeRange {
+	clipped := []timeRange{}
+	for _, r := range ranges {
+		if !r.End.After(start) {
+			continue
+		}
+		if r.Start.Before(start) {
+			r.Start = start
+		}
+		clipped = append(clipped, r)
+	}
+	return clipped
+}
+
+// inRanges reports whether t falls in one of the ranges
+func inRanges(ranges []timeRange, t time.Time) bool {
+	for _, r := range ranges {
+		if !t.Before(r.Start) && !t.After(r.End) {
+			return true
+		}
+	}
+	return false
+}
+
+// store appends the entries fetched for the query, leaving out those of ranges
+// the file already holds. When they are every entry of the query's range as of
+// fetchedAt, that range is recorded as held.
+func (c *searchCache) store(query tinyhatchet.Query, entries []tinyhatchet.LogEntry, complete bool, fetchedAt time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	err := os.MkdirAll(c.dir, 0700)
+	if err != nil {
+		return fmt.Errorf("create cache directory: %w", err)
+	}
+	key := cacheKey(query)
+	f, ok := c.files[key]
+	if !ok {
+		f = &cacheFile{}
+		c.files[key] = f
+	}
+	f.Used = fetchedAt
+
+	fresh := make([]tinyhatchet.LogEntry, 0, len(entries))
+	for _, entry := range entries {
+		if !inRanges(f.Ranges, entry.Timestamp) {
+			fresh = append(fresh, entry)
+		}
+	}
+	if len(fresh) > 0 {
+		err = c.append(key, fresh)
+		if err != nil {
+			return err
+		}
+		f.Lines += len(fresh)
+	}
+	if complete {
+		r := queryRange(query, fetchedAt)
+		if settled := fetchedAt.Add(-cacheSettle); r.End.After(settled) {
+			r.End = settled
+		}
+		if r.End.After(r.Start) {
+			f.Ranges = addRange(f.Ranges, r)
+		}
+	}
+	if f.Lines > 2*f.Kept+cacheCompactSlack {
+		err = c.compact(key, fetchedAt)
+		if err != nil {
+			return err
+		}
+	}
+	err = c.evict()
+	if err != nil {
+		return err
+	}
+	return c.writeIndex()
+}
+
+func (c *searchCache) append(key string, entries []tinyhatchet.LogEntry) error {
+	f, err := os.OpenFile(c.entriesPath(key), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("open cache file: %w", err)
+	}
+	err = encodeEntries(f, entries)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func encodeEntries(f *os.File, entries []tinyhatchet.LogEntry) error {
+	w := bufio.NewWriter(f)
+	encoder := json.NewEncoder(w)
+	for _, entry := range entries {
+		err := encoder.Encode(entry)
+		if err != nil {
+			return fmt.Errorf("marshal cached entry: %w", err)
+		}
+	}
+	err := w.Flush()
+	if err != nil {
+		return fmt.Errorf("write cache file: %w", err)
+	}
+	return nil
+}
+
+// compact rewrites the file of key without duplicates, oldest first, keeping
+// at most cacheMaxEntries entries no older than cacheMaxAge. The ranges the file
+// holds are cut back to start at the oldest entry that was kept.
+func (c *searchCache) compact(key string, now time.Time) error {
+	// entries stamped ahead of now by a skewed clock are kept too
+	entries, err := c.read(key, timeRange{Start: now.Add(-cacheMaxAge), End: now.Add(cacheMaxAge)})
+	if err != nil {
+		return err
+	}
+	sortEntries(entries, tinyhatchet.OrderAscending)
+	cut := now.Add(-cacheMaxAge)
+	if len(entries) > cacheMaxEntries {
+		// keep every entry of the oldest timestamp kept, so the range from there on stays complete
+		cut = entries[len(entries)-cacheMaxEntries].Timestamp
+		first := sort.Search(len(entries), func(i int) bool { return !entries[i].Timestamp.Before(cut) })
+		entries = entries[first:]
+	}
+
+	path := c.entriesPath(key)
+	tmp, err := ioutil.TempFile(c.dir, "compact-*")
+	if err != nil {
+		return fmt.Errorf("create cache file: %w", err)
+	}
+	err = encodeEntries(tmp, entries)
+	if err == nil {
+		err = tmp.Chmod(0600)
+	}
+	if err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	err = tmp.Close()
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("replace cache file: %w", err)
+	}
+
+	f := c.files[key]
+	f.Ranges = clipRanges(f.Ranges, cut)
+	f.Lines, f.Kept = len(entries), len(entries)
+	return nil
+}
+
+// evict removes the least recently used files beyond cacheMaxFiles
+func (c *searchCache) evict() error {
+	for len(c.files) > cacheMaxFiles {
+		var oldest string
+		for key, f := range c.files {
+			if oldest == "" || f.Used.Before(c.files[oldest].Used) {
+				oldest = key
+			}
+		}
+		err := os.Remove(c.entriesPath(oldest))
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("remove cache file: %w", err)
+		}
+		delete(c.files, oldest)
+	}
+	return nil
+}
+
+func (c *searchCache) writeIndex() error {
+	body, err := json.Marshal(c.files)
+	if err != nil {
+		return fmt.Errorf("marshal cache index: %w", err)
+	}
+	err = ioutil.WriteFile(filepath.Join(c.dir, cacheIndexFile), body, 0600)
+	if err != nil {
+		return fmt.Errorf("write cache index: %w", err)
+	}
+	return nil
+}
+
+// read returns the entries of key's file in the range, each once, in file order
+func (c *searchCache) read(key string, r timeRange) ([]tinyhatchet.LogEntry, error) {
+	entries := []tinyhatchet.LogEntry{}
+	f, err := os.Open(c.entriesPath(key))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return entries, nil
+		}
+		return nil, fmt.Errorf("open cache file: %w", err)
+	}
+	defer f.Close()
+
+	seen := map[string]struct{}{}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 16*1024*1024)
+	for scanner.Scan() {
+		var entry tinyhatchet.LogEntry
+		if json.Unmarshal(scanner.Bytes(), &entry) != nil {
+			continue
+		}
+		if entry.Timestamp.Before(r.Start) || entry.Timestamp.After(r.End) {
+			continue
+		}
+		key := entryKey(entry)
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read cache file: %w", err)
+	}
+	return entries, nil
+}
+
+// entries returns the cached entries in the query's range, oldest first
+// unless the query asks for the newest first. A file is at most a little over
+// twice cacheMaxEntries long, which bounds what a search reads into memory.
+func (c *searchCache) entries(query tinyhatchet.Query, now time.Time) ([]tinyhatchet.LogEntry, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := cacheKey(query)
+	if f, ok := c.files[key]; ok {
+		f.Used = now
+	}
+	entries, err := c.read(key, queryRange(query, now))
+	if err != nil {
+		return nil, err
+	}
+	sortEntries(entries, query.Order)
+	return entries, nil
+}
+
+func sortEntries(entries []tinyhatchet.LogEntry, order string) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		if order == tinyhatchet.OrderDescending {
+			return entries[i].Timestamp.After(entries[j].Timestamp)
+		}
+		return entries[i].Timestamp.Before(entries[j].Timestamp)
+	})
+}
+
+// fill fetches the parts of the query's range the cache is missing, a page at a time,
+// and stores them
+func (c *searchCache) fill(ctx context.Context, query tinyhatchet.Query, now time.Time) error {
+	for _, gap := range c.missing(query, now) {
+		gapQuery := query
+		gapQuery.Start = ""
+		if !gap.Start.IsZero() {
+			gapQuery.Start = gap.Start.UTC().Format(time.RFC3339Nano)
+		}
+		gapQuery.End = gap.End.UTC().Format(time.RFC3339Nano)
+		gapQuery.Limit, gapQuery.Offset = pageSize, 0
+		fetch := func(query tinyhatchet.Query) (tinyhatchet.Page, error) {
+			return client.SearchPage(ctx, query)
+		}
+		err := eachPage(gapQuery, fetch, func(page tinyhatchet.Page) error {
+			return c.store(gapQuery, page.Entries, page.Next == nil, now)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// search answers the query from the cache, first fetching the parts it is missing
+// unless in offline mode. It reports whether the server could not be reached,
+// in which case the entries are only those that were cached.
+func (c *searchCache) search(ctx context.Context, query tinyhatchet.Query, now time.Time) ([]tinyhatchet.LogEntry, bool, error) {
+	offline := offlineMode
+	if !offline {
+		err := c.fill(ctx, query, now)
+		if tinyhatchet.IsUnreachable(err) {
+			offline = true
+		} else if err != nil {
+			return nil, false, err
+		}
+	}
+	entries, err := c.entries(query, now)
+	return entries, offline, err
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/TinyHatchet/client/tinyhatchet"
+)
+
+// at is minute m of a fixed day, to keep the ranges below readable
+func at(m int) time.Time {
+	return time.Date(2021, 6, 1, 0, m, 0, 0, time.UTC)
+}
+
+func span(start, end int) timeRange {
+	return timeRange{Start: at(start), End: at(end)}
+}
+
+func TestSubtractRanges(t *testing.T) {
+	tests := []struct {
+		name    string
+		r       timeRange
+		covered []timeRange
+		want    []timeRange
+	}{
+		{"nothing covered", span(10, 20), nil, []timeRange{span(10, 20)}},
+		{"fully covered", span(10, 20), []timeRange{span(0, 30)}, []timeRange{}},
+		{"covered exactly", span(10, 20), []timeRange{span(10, 20)}, []timeRange{}},
+		{"covered before", span(10, 20), []timeRange{span(0, 5)}, []timeRange{span(10, 20)}},
+		{"covered after", span(10, 20), []timeRange{span(25, 30)}, []timeRange{span(10, 20)}},
+		{"touching start", span(10, 20), []timeRange{span(0, 10)}, []timeRange{span(10, 20)}},
+		{"touching end", span(10, 20), []timeRange{span(20, 30)}, []timeRange{span(10, 20)}},
+		{"overlapping start", span(10, 20), []timeRange{span(5, 15)}, []timeRange{span(15, 20)}},
+		{"overlapping end", span(10, 20), []timeRange{span(15, 25)}, []timeRange{span(10, 15)}},
+		{"inside", span(10, 20), []timeRange{span(12, 18)}, []timeRange{span(10, 12), span(18, 20)}},
+		{"several inside", span(10, 20), []timeRange{span(11, 12), span(14, 16)}, []timeRange{span(10, 11), span(12, 14), span(16, 20)}},
+		{"gap between", span(0, 30), []timeRange{span(0, 10), span(20, 30)}, []timeRange{span(10, 20)}},
+		{"empty range", span(10, 10), nil, []timeRange{}},
+		{"from the zero time", timeRange{End: at(20)}, []timeRange{span(10, 30)}, []timeRange{{End: at(10)}}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := subtractRanges(test.r, test.covered)
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("subtractRanges(%v, %v) = %v, want %v", test.r, test.covered, got, test.want)
+			}
+		})
+	}
+}
+
+func TestAddRange(t *testing.T) {
+	tests := []struct {
+		name   string
+		ranges []timeRange
+		r      timeRange
+		want   []timeRange
+	}{
+		{"first", nil, span(10, 20), []timeRange{span(10, 20)}},
+		{"before", []timeRange{span(10, 20)}, span(0, 5), []timeRange{span(0, 5), span(10, 20)}},
+		{"after", []timeRange{span(10, 20)}, span(25, 30), []timeRange{span(10, 20), span(25, 30)}},
+		{"touching start", []timeRange{span(10, 20)}, span(0, 10), []timeRange{span(0, 20)}},
+		{"touching end", []timeRange{span(10, 20)}, span(20, 30), []timeRange{span(10, 30)}},
+		{"overlapping start", []timeRange{span(10, 20)}, span(5, 15), []timeRange{span(5, 20)}},
+		{"overlapping end", []timeRange{span(10, 20)}, span(15, 25), []timeRange{span(10, 25)}},
+		{"inside", []timeRange{span(10, 20)}, span(12, 18), []timeRange{span(10, 20)}},
+		{"around", []timeRange{span(10, 20)}, span(5, 25), []timeRange{span(5, 25)}},
+		{"bridging", []timeRange{span(0, 10), span(20, 30)}, span(10, 20), []timeRange{span(0, 30)}},
+		{"over several", []timeRange{span(0, 5), span(10, 15), span(20, 25), span(40, 50)}, span(3, 22), []timeRange{span(0, 25), span(40, 50)}},
+		{"into a gap", []timeRange{span(0, 5), span(20, 25)}, span(10, 15), []timeRange{span(0, 5), span(10, 15), span(20, 25)}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := addRange(test.ranges, test.r)
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("addRange(%v, %v) = %v, want %v", test.ranges, test.r, got, test.want)
+			}
+		})
+	}
+}
+
+func TestClipRanges(t *testing.T) {
+	tests := []struct {
+		name   string
+		ranges []timeRange
+		start  time.Time
+		want   []timeRange
+	}{
+		{"all after", []timeRange{span(10, 20)}, at(5), []timeRange{span(10, 20)}},
+		{"all before", []timeRange{span(10, 20)}, at(25), []timeRange{}},
+		{"ending at start", []timeRange{span(10, 20)}, at(20), []timeRange{}},
+		{"cut", []timeRange{span(0, 10), span(20, 30)}, at(25), []timeRange{span(25, 30)}},
+		{"from the zero time", []timeRange{{End: at(20)}}, at(10), []timeRange{span(10, 20)}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := clipRanges(test.ranges, test.start)
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("clipRanges(%v, %v) = %v, want %v", test.ranges, test.start, got, test.want)
+			}
+		})
+	}
+}
+
+func TestCacheStoreCompacts(t *testing.T) {
+	c := &searchCache{dir: t.TempDir(), files: map[string]*cacheFile{}}
+	now := time.Now().UTC()
+	query := tinyhatchet.Query{Start: now.Add(-time.Hour).Format(time.RFC3339Nano)}
+	entries := make([]tinyhatchet.LogEntry, 1000)
+	for i := range entries {
+		entries[i] = tinyhatchet.LogEntry{Timestamp: now.Add(-time.Duration(i) * time.Second), Text: fmt.Sprint(i)}
+	}
+
+	// searches ending at now fetch the same recent entries again and again
+	for i := 0; i < 20; i++ {
+		err := c.store(query, entries, false, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if f := c.files[cacheKey(query)]; f.Lines > 2*len(entries)+cacheCompactSlack {
+		t.Errorf("file grew to %d lines for %d entries", f.Lines, len(entries))
+	}
+	got, err := c.entries(query, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(entries) {
+		t.Errorf("read %d entries, want %d", len(got), len(entries))
+	}
+}
+
+func TestCacheSearchFetchesGaps(t *testing.T) {
+	// the server holds an entry every minute and answers start, end, limit and offset
+	var requested []timeRange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		values := r.URL.Query()
+		start, _ := time.Parse(time.RFC3339Nano, values.Get("start"))
+		end, _ := time.Parse(time.RFC3339Nano, values.Get("end"))
+		var limit, offset int
+		fmt.Sscan(values.Get("limit"), &limit)
+		fmt.Sscan(values.Get("offset"), &offset)
+		if offset == 0 {
+			requested = append(requested, timeRange{Start: start, End: end})
+		}
+		entries := []tinyhatchet.LogEntry{}
+		for m := 0; m < 60; m++ {
+			if !at(m).Before(start) && !at(m).After(end) {
+				entries = append(entries, tinyhatchet.LogEntry{Timestamp: at(m), Text: fmt.Sprint(m)})
+			}
+		}
+		if offset > len(entries) {
+			offset = len(entries)
+		}
+		entries = entries[offset:]
+		if limit > 0 && limit < len(entries) {
+			entries = entries[:limit]
+		}
+		json.NewEncoder(w).Encode(entries)
+	}))
+	defer server.Close()
+	var err error
+	client, err = tinyhatchet.NewClient(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := &searchCache{dir: t.TempDir(), files: map[string]*cacheFile{}}
+	now := at(60)
+	rangeQuery := func(start, end int) tinyhatchet.Query {
+		return tinyhatchet.Query{Start: at(start).Format(time.RFC3339Nano), End: at(end).Format(time.RFC3339Nano)}
+	}
+	held := []tinyhatchet.LogEntry{}
+	for m := 10; m <= 20; m++ {
+		held = append(held, tinyhatchet.LogEntry{Timestamp: at(m), Text: fmt.Sprint(m)})
+	}
+	err = c.store(rangeQuery(10, 20), held, true, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries, offline, err := c.search(context.Background(), rangeQuery(0, 30), now)
+	if err != nil || offline {
+		t.Fatalf("search: %v, offline %v", err, offline)
+	}
+	if want := []timeRange{span(0, 10), span(20, 30)}; !reflect.DeepEqual(requested, want) {
+		t.Errorf("requested %v, want only the gaps %v", requested, want)
+	}
+	if len(entries) != 31 {
+		t.Errorf("got %d entries, want 31", len(entries))
+	}
+
+	requested = nil
+	entries, _, err = c.search(context.Background(), rangeQuery(5, 25), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(requested) != 0 {
+		t.Errorf("requested %v for a range the cache holds", requested)
+	}
+	if len(entries) != 21 {
+		t.Errorf("got %d entries, want 21", len(entries))
+	}
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/TinyHatchet/client/tinyhatchet"
+)
+
+const (
+	sendBatchSize = 100
+	cliPageSize   = 1000
+)
+
+// runCommand executes a non-interactive subcommand instead of starting the TUI
+func runCommand(name string, args []string) error {
+	switch name {
+	case "search":
+		return searchCommand(args)
+	case "send":
+		return sendCommand(args)
+	}
+	return fmt.Errorf("unknown command %q", name)
+}
+
+func searchCommand(args []string) error {
+	flags := flag.NewFlagSet("search", flag.ExitOnError)
+	inputs := SavedSearch{}
+	var output, tmpl, filterQuery, saved, order string
+	var asJSON bool
+	flags.StringVar(&inputs.Start, "start", "", "start of the time range (-15m, 1h ago, yesterday 09:00, 2021-06-01 11:22), read in -timezone")
+	flags.StringVar(&inputs.End, "end", "", "end of the time range, same forms as -start")
+	flags.StringVar(&inputs.Tags, "tags", "", "comma separated tags to match")
+	flags.StringVar(&inputs.Text, "query", "", "full-text query sent to the server")
+	flags.StringVar(&inputs.Level, "level", "", "minimum level of the entries shown: debug, info, warn or error")
+	flags.StringVar(&filterQuery, "filter", "", `filter applied to the results, e.g. 'error AND NOT tag:debug', "phrase", /regexp/`)
+	flags.StringVar(&order, "order", "", "oldest or newest first, asked of the server")
+	flags.StringVar(&output, "output", string(formatText), "output format: text, jsonl or csv")
+	flags.StringVar(&tmpl, "format", "", `Go template for each entry, overrides -output, e.g. '{{time "clock" .Timestamp}} [{{join .Tags ","}}] {{.Text}}'`+
+		"\nfunctions: join, time, unix, truncate, color, bold, level, upper, lower")
+	flags.BoolVar(&asJSON, "json", false, "shorthand for -output jsonl")
+	flags.StringVar(&saved, "saved", "", "run the named saved search, other flags override its inputs")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if saved != "" {
+		inputs, err = savedInputs(saved, inputs, flags)
+		if err != nil {
+			return err
+		}
+	}
+	if asJSON {
+		output = string(formatJSONL)
+	}
+	format, err := parseOutputFormat(output)
+	if err != nil {
+		return err
+	}
+	query := inputs.query()
+	query.Order, err = parseOrder(order)
+	if err != nil {
+		return err
+	}
+	now := time.Now().In(displayZone)
+	startErr, endErr := resolveRange(&query, now)
+	if startErr != nil {
+		return fmt.Errorf("start: %w", startErr)
+	}
+	if endErr != nil {
+		return fmt.Errorf("end: %w", endErr)
+	}
+	minLevel, err := parseLevel(inputs.Level)
+	if err != nil {
+		return err
+	}
+	entryFilter, err := parseFilter(filterQuery)
+	if err != nil {
+		return fmt.Errorf("filter: %w", err)
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	var writer *entryWriter
+	if tmpl != "" {
+		writer, err = newTemplateWriter(w, tmpl)
+	} else {
+		writer, err = newEntryWriter(w, format)
+	}
+	if err != nil {
+		return err
+	}
+	search := query
+	query.Limit = cliPageSize
+	fetch := func(query tinyhatchet.Query) (tinyhatchet.Page, error) {
+		return searchPage(search, query, now)
+	}
+	err = eachPage(query, fetch, func(page tinyhatchet.Page) error {
+		entries := page.Entries
+		if minLevel != levelUnknown {
+			entries = filterLevel(entries, minLevel)
+		}
+		if entryFilter != nil {
+			entries = filterEntries(entries, entryFilter)
+		}
+		return writer.Write(entries)
+	})
+	if err != nil {
+		return err
+	}
+	err = writer.Flush()
+	if err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// searchPage fetches a page of the search and caches it. In offline mode, when the
+// cache holds part of the range, or when the server cannot be reached for the first
+// page, the cache answers in one page, after fetching the ranges it is missing.
+func searchPage(search, query tinyhatchet.Query, now time.Time) (tinyhatchet.Page, error) {
+	if resultCache != nil && query.Offset == 0 && (offlineMode || resultCache.holdsAny(search, now)) {
+		entries, offline, err := resultCache.search(context.Background(), search, now)
+		if offline && !offlineMode {
+			log.Println("server unreachable, showing cached entries")
+		}
+		return tinyhatchet.Page{Entries: entries}, err
+	}
+	if !offlineMode {
+		page, err := client.SearchPage(context.Background(), query)
+		if err == nil && resultCache != nil {
+			err = resultCache.store(search, page.Entries, page.Next == nil, now)
+			if err != nil {
+				log.Println(err)
+			}
+			return page, nil
+		}
+		if resultCache == nil || query.Offset > 0 || !tinyhatchet.IsUnreachable(err) {
+			return page, err
+		}
+		log.Printf("%v, showing cached entries", err)
+	}
+	if resultCache == nil {
+		return tinyhatchet.Page{}, errCacheDisabled
+	}
+	entries, err := resultCache.entries(search, now)
+	return tinyhatchet.Page{Entries: entries}, err
+}
+
+// savedInputs returns the named saved search with the search flags that were given on top
+func savedInputs(name string, flagInputs SavedSearch, flags *flag.FlagSet) (SavedSearch, error) {
+	profile := appConfig.Profile()
+	inputs, ok := profile.SavedSearches[name]
+	if !ok {
+		names := profile.SavedSearchNames()
+		if len(names) == 0 {
+			return SavedSearch{}, fmt.Errorf("no saved search named %q, the profile has none", name)
+		}
+		return SavedSearch{}, fmt.Errorf("no saved search named %q, choose one of: %s", name, strings.Join(names, ", "))
+	}
+	flags.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "start":
+			inputs.Start = flagInputs.Start
+		case "end":
+			inputs.End = flagInputs.End
+		case "tags":
+			inputs.Tags = flagInputs.Tags
+		case "query":
+			inputs.Text = flagInputs.Text
+		case "level":
+			inputs.Level = flagInputs.Level
+		}
+	})
+	return inputs, nil
+}
+
+func sendCommand(args []string) error {
+	flags := flag.NewFlagSet("send", flag.ExitOnError)
+	token := tinyhatchet.Token{}
+	var text, tags string
+	flags.StringVar(&token.ID, "token-id", "", "API token id, defaults to the global token")
+	flags.StringVar(&token.Secret, "token-secret", "", "API token secret, defaults to the global token")
+	flags.StringVar(&text, "text", "", "text of the entry, each line of stdin is sent as an entry when empty")
+	flags.StringVar(&tags, "tags", "", "comma separated tags added to every entry")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if token.ID != "" || token.Secret != "" {
+		client.Token = token
+	}
+
+	var tagList []string
+	if tags != "" {
+		tagList = strings.Split(tags, ",")
+	}
+
+	if text != "" {
+		entry := tinyhatchet.LogEntry{Timestamp: time.Now(), Text: text, Tags: tagList}
+		return client.SendEntries(context.Background(), []tinyhatchet.LogEntry{entry})
+	}
+
+	batch := make([]tinyhatchet.LogEntry, 0, sendBatchSize)
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		if scanner.Text() == "" {
+			continue
+		}
+		batch = append(batch, tinyhatchet.LogEntry{Timestamp: time.Now(), Text: scanner.Text(), Tags: tagList})
+		if len(batch) == sendBatchSize {
+			err = client.SendEntries(context.Background(), batch)
+			if err != nil {
+				return err
+			}
+			batch = batch[:0]
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return fmt.Errorf("read stdin: %w", err)
+	}
+	if len(batch) == 0 {
+		return nil
+	}
+	return client.SendEntries(context.Background(), batch)
+}
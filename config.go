@@ -0,0 +1,315 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/TinyHatchet/client/tinyhatchet"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	defaultProfileName = "default"
+
+	envServerURL = "TINYHATCHET_SERVER"
+)
+
+var (
+	appConfig  Config
+	configPath string
+)
+
+var profileNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+type Config struct {
+	CurrentProfile string              `yaml:"currentprofile"`
+	Profiles       map[string]*Profile `yaml:"profiles"`
+	// ContextWindow is how far before and after an entry "show surrounding logs" looks, like 2m
+	ContextWindow string `yaml:"contextwindow,omitempty"`
+	// TimeZone is the zone times are shown and read in: local, UTC or an IANA name like Europe/Paris
+	TimeZone string `yaml:"timezone,omitempty"`
+	// DisableCache stops fetched entries from being kept under the user cache directory
+	DisableCache bool `yaml:"disablecache,omitempty"`
+
+	// Fields written by versions without profiles, moved into the default profile on load
+	ServerURL      string `yaml:"serverurl,omitempty"`
+	EmailAddress   string `yaml:"emailaddress,omitempty"`
+	DebugPath      string `yaml:"debugpath,omitempty"`
+	APITokenID     string `yaml:"apitokenid,omitempty"`
+	APITokenSecret string `yaml:"apitokensecret,omitempty"`
+}
+
+// Profile holds everything needed to talk to one server with one account
+type Profile struct {
+	ServerURL      string `yaml:"serverurl,omitempty"`
+	EmailAddress   string `yaml:"emailaddress,omitempty"`
+	DebugPath      string `yaml:"debugpath,omitempty"`
+	APITokenID     string `yaml:"apitokenid,omitempty"`
+	APITokenSecret string `yaml:"apitokensecret,omitempty"`
+
+	SavedSearches map[string]SavedSearch `yaml:"savedsearches,omitempty"`
+}
+
+// SavedSearch holds the search inputs as they were typed,
+// so relative times are resolved again every time it runs.
+// The search history is stored the same way.
+type SavedSearch struct {
+	Start string `yaml:"start,omitempty" json:"start,omitempty"`
+	End   string `yaml:"end,omitempty" json:"end,omitempty"`
+	Tags  string `yaml:"tags,omitempty" json:"tags,omitempty"`
+	Text  string `yaml:"text,omitempty" json:"text,omitempty"`
+	// Level is the minimum level shown, filtered on the client
+	Level string `yaml:"level,omitempty" json:"level,omitempty"`
+}
+
+func (s SavedSearch) query() tinyhatchet.Query {
+	return tinyhatchet.Query{Start: s.Start, End: s.End, Tags: s.Tags, Text: s.Text}
+}
+
+func (p *Profile) SaveSearch(name string, search SavedSearch) {
+	if p.SavedSearches == nil {
+		p.SavedSearches = map[string]SavedSearch{}
+	}
+	p.SavedSearches[name] = search
+}
+
+func (p Profile) SavedSearchNames() []string {
+	names := make([]string, 0, len(p.SavedSearches))
+	for name := range p.SavedSearches {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (c *Config) LoadFromFile(path string) error {
+	body, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("read config file: %w", err)
+	}
+	err = yaml.Unmarshal(body, c)
+	if err != nil {
+		return fmt.Errorf("unmarshal config: %w", err)
+	}
+	c.migrate()
+	if c.CurrentProfile != "" {
+		err = validateProfileName(c.CurrentProfile)
+		if err != nil {
+			return fmt.Errorf("config: %w", err)
+		}
+	}
+	for name := range c.Profiles {
+		err = validateProfileName(name)
+		if err != nil {
+			return fmt.Errorf("config: %w", err)
+		}
+	}
+	return nil
+}
+
+// validateProfileName keeps profile names safe to use in the names of the
+// session, history and cache files of the profile
+func validateProfileName(name string) error {
+	if !profileNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid profile name %q, use only letters, digits, _ and -", name)
+	}
+	return nil
+}
+
+// migrate moves the fields of a config without profiles into the default profile
+func (c *Config) migrate() {
+	if c.ServerURL == "" && c.EmailAddress == "" && c.DebugPath == "" && c.APITokenID == "" && c.APITokenSecret == "" {
+		return
+	}
+	legacy := Profile{
+		ServerURL:      c.ServerURL,
+		EmailAddress:   c.EmailAddress,
+		DebugPath:      c.DebugPath,
+		APITokenID:     c.APITokenID,
+		APITokenSecret: c.APITokenSecret,
+	}
+	if c.Profiles == nil {
+		c.Profiles = map[string]*Profile{}
+	}
+	if _, ok := c.Profiles[defaultProfileName]; !ok {
+		c.Profiles[defaultProfileName] = &legacy
+	}
+	c.ServerURL, c.EmailAddress, c.DebugPath, c.APITokenID, c.APITokenSecret = "", "", "", "", ""
+}
+
+func (c Config) WriteOut(path string) error {
+	body, err := yaml.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("marshal config: %w", err)
+	}
+	err = ioutil.WriteFile(path, body, 0600)
+	if err != nil {
+		return fmt.Errorf("write config file %w", err)
+	}
+	return nil
+}
+
+// Profile returns the current profile, creating it if it does not exist yet
+func (c *Config) Profile() *Profile {
+	if c.CurrentProfile == "" {
+		c.CurrentProfile = defaultProfileName
+	}
+	return c.AddProfile(c.CurrentProfile)
+}
+
+// AddProfile returns the named profile, creating an empty one if needed
+func (c *Config) AddProfile(name string) *Profile {
+	if c.Profiles == nil {
+		c.Profiles = map[string]*Profile{}
+	}
+	p, ok := c.Profiles[name]
+	if !ok {
+		p = &Profile{}
+		c.Profiles[name] = p
+	}
+	return p
+}
+
+func (c Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// resolveServerURL uses override when it is set, then the profile, falling
+// back to the public server, and checks that the result is usable
+func resolveServerURL(override string, profile Profile) (string, error) {
+	serverURL := tinyhatchet.DefaultServerURL
+	switch {
+	case override != "":
+		serverURL = override
+	case profile.ServerURL != "":
+		serverURL = profile.ServerURL
+	}
+
+	u, err := url.Parse(serverURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid server url %q: %w", serverURL, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("invalid server url %q: scheme must be http or https", serverURL)
+	}
+	if u.Host == "" {
+		return "", fmt.Errorf("invalid server url %q: missing host", serverURL)
+	}
+	if u.RawQuery != "" || u.Fragment != "" {
+		return "", fmt.Errorf("invalid server url %q: must not have a query or fragment", serverURL)
+	}
+	return serverURL, nil
+}
+
+// overrides are the settings given by flags or environment variables at startup.
+// They take precedence over the profile that was active when the client started.
+type overrides struct {
+	ServerURL string
+	Token     tinyhatchet.Token
+}
+
+func envOverrides(flags overrides) overrides {
+	o := flags
+	if o.ServerURL == "" {
+		o.ServerURL = os.Getenv(envServerURL)
+	}
+	if o.Token.ID == "" && o.Token.Secret == "" {
+		o.Token = tinyhatchet.Token{ID: os.Getenv(envTokenID), Secret: os.Getenv(envTokenSecret)}
+	}
+	return o
+}
+
+// connection is what connect sets up for a profile. It is built without touching
+// the globals, so that switching profiles can connect in a tea.Cmd and apply the
+// result in Update.
+type connection struct {
+	client      *tinyhatchet.Client
+	token       tinyhatchet.Token
+	sessionPath string
+	historyPath string
+	cache       *searchCache
+	loggedIn    bool
+}
+
+// connect builds the client for the current profile and restores its session,
+// reporting whether the user is logged in
+func connect(o overrides) (bool, error) {
+	conn, err := dial(appConfig.CurrentProfile, *appConfig.Profile(), !appConfig.DisableCache, o)
+	if conn.client != nil {
+		conn.use()
+	}
+	return conn.loggedIn, err
+}
+
+// dial builds the client for the named profile and restores its session.
+// The client is set even when the session could not be restored.
+func dial(name string, profile Profile, cache bool, o overrides) (connection, error) {
+	serverURL, err := resolveServerURL(o.ServerURL, profile)
+	if err != nil {
+		return connection{}, err
+	}
+	c, err := tinyhatchet.NewClient(serverURL)
+	if err != nil {
+		return connection{}, err
+	}
+	conn := connection{
+		client:      c,
+		token:       resolveToken(o.Token, profile),
+		sessionPath: sessionFilePath(configPath, name),
+		historyPath: historyFilePath(configPath, name),
+	}
+	if cache {
+		conn.cache = openCache(c.ServerURL, name)
+	}
+
+	if conn.token.IsSet() {
+		c.Token = conn.token
+		conn.loggedIn = true
+		return conn, nil
+	}
+	conn.loggedIn, err = loadSession(c, conn.sessionPath)
+	return conn, err
+}
+
+// use makes the connection the current one
+func (conn connection) use() {
+	client = conn.client
+	appToken = conn.token
+	sessionPath = conn.sessionPath
+	historyPath = conn.historyPath
+	resultCache = conn.cache
+}
+
+// sessionFilePath places the session file next to the config file,
+// ~/.tinyhatchet.config becomes ~/.tinyhatchet.session for the default
+// profile and ~/.tinyhatchet.staging.session for a profile named staging
+func sessionFilePath(configPath, profile string) string {
+	return profileFilePath(configPath, profile, "session")
+}
+
+// historyFilePath places the search history next to the session file
+func historyFilePath(configPath, profile string) string {
+	return profileFilePath(configPath, profile, "history")
+}
+
+func profileFilePath(configPath, profile, ext string) string {
+	base := strings.TrimSuffix(configPath, filepath.Ext(configPath))
+	if profile == defaultProfileName {
+		return base + "." + ext
+	}
+	return base + "." + profile + "." + ext
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/TinyHatchet/client/tinyhatchet"
+	"github.com/atotto/clipboard"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	detailHeaderHeight = 2
+	detailFooterHeight = 2
+)
+
+var detailLabelStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("240")).Width(18)
+
+// entryDetail shows a single search result in full in a scrollable viewport
+type entryDetail struct {
+	active   bool
+	entry    tinyhatchet.LogEntry
+	viewport viewport.Model
+	status   string
+}
+
+type copiedEntry struct{}
+
+func newEntryDetail(entry tinyhatchet.LogEntry, w, h int) entryDetail {
+	d := entryDetail{active: true, entry: entry}
+	d.setSize(w, h)
+	return d
+}
+
+func (d *entryDetail) setSize(w, h int) {
+	d.viewport.Width = w
+	d.viewport.Height = h - detailHeaderHeight - detailFooterHeight
+	d.viewport.SetContent(d.content())
+}
+
+func (d entryDetail) content() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%s%s %s\n", detailLabelStyle.Render("Timestamp"), d.entry.Timestamp.In(displayZone).Format(time.RFC3339Nano), blurredStyle.Render(zoneName(displayZone)))
+	fmt.Fprintf(&b, "%s%s\n", detailLabelStyle.Render("Timestamp (UTC)"), d.entry.Timestamp.UTC().Format(time.RFC3339Nano))
+	fmt.Fprintf(&b, "%s%s\n\n", detailLabelStyle.Render("Tags"), strings.Join(d.entry.Tags, ", "))
+
+	text := d.entry.Text
+	if pretty, ok := prettyJSON(text); ok {
+		text = pretty
+	}
+	b.WriteString(lipgloss.NewStyle().Width(d.viewport.Width).Render(text))
+	return b.String()
+}
+
+// prettyJSON indents text if it is a JSON object or array
+func prettyJSON(text string) (string, bool) {
+	trimmed := strings.TrimSpace(text)
+	if !strings.HasPrefix(trimmed, "{") && !strings.HasPrefix(trimmed, "[") {
+		return "", false
+	}
+	buf := bytes.NewBuffer(nil)
+	err := json.Indent(buf, []byte(trimmed), "", "  ")
+	if err != nil {
+		return "", false
+	}
+	return buf.String(), true
+}
+
+func (d entryDetail) View() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("Log Entry"))
+	b.WriteString("\n\n")
+	b.WriteString(d.viewport.View())
+	b.WriteString("\n\n")
+	if d.status != "" {
+		b.WriteString(d.status)
+	} else {
+		b.WriteString(blurredStyle.Render(fmt.Sprintf("↑/↓ scroll • c copy • esc back • %3.f%%", d.viewport.ScrollPercent()*100)))
+	}
+	return b.String()
+}
+
+// copyEntry puts the entry on the clipboard in the same form as the text export
+func copyEntry(entry tinyhatchet.LogEntry) tea.Cmd {
+	return func() tea.Msg {
+		buf := bytes.NewBuffer(nil)
+		err := writeEntries(buf, formatText, []tinyhatchet.LogEntry{entry})
+		if err != nil {
+			return err
+		}
+		err = clipboard.WriteAll(strings.TrimSuffix(buf.String(), "\n"))
+		if err != nil {
+			return fmt.Errorf("copy to clipboard: %w", err)
+		}
+		return copiedEntry{}
+	}
+}
+
+// updateDetail handles input while the detail view is open
+func (s *searchMenu) updateDetail(msg tea.KeyMsg) tea.Cmd {
+	s.detail.status = ""
+	switch msg.String() {
+	case "ctrl+c", "q":
+		return tea.Quit
+	case "esc", "backspace":
+		s.detail.active = false
+		return nil
+	case "c", "y":
+		return copyEntry(s.detail.entry)
+	}
+	var cmd tea.Cmd
+	s.detail.viewport, cmd = s.detail.viewport.Update(msg)
+	return cmd
+}
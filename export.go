@@ -0,0 +1,240 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/TinyHatchet/client/tinyhatchet"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type outputFormat string
+
+const (
+	formatText  outputFormat = "text"
+	formatJSONL outputFormat = "jsonl"
+	formatCSV   outputFormat = "csv"
+)
+
+var outputFormats = []outputFormat{formatText, formatJSONL, formatCSV}
+
+func parseOutputFormat(s string) (outputFormat, error) {
+	for _, format := range outputFormats {
+		if string(format) == s {
+			return format, nil
+		}
+	}
+	return "", fmt.Errorf("unknown output format %q, expected one of text, jsonl or csv", s)
+}
+
+// extension is the file extension used for exports in the format
+func (f outputFormat) extension() string {
+	if f == formatText {
+		return ".txt"
+	}
+	return "." + string(f)
+}
+
+// writeEntries writes the entries to w in the given format
+func writeEntries(w io.Writer, format outputFormat, entries []tinyhatchet.LogEntry) error {
+	writer, err := newEntryWriter(w, format)
+	if err != nil {
+		return err
+	}
+	err = writer.Write(entries)
+	if err != nil {
+		return err
+	}
+	return writer.Flush()
+}
+
+// entryWriter writes entries in one format over any number of calls,
+// so results can be written page by page as they arrive
+type entryWriter struct {
+	w      io.Writer
+	format outputFormat
+	json   *json.Encoder
+	csv    *csv.Writer
+	tmpl   *template.Template
+}
+
+func newEntryWriter(w io.Writer, format outputFormat) (*entryWriter, error) {
+	writer := &entryWriter{w: w, format: format}
+	switch format {
+	case formatText:
+	case formatJSONL:
+		writer.json = json.NewEncoder(w)
+	case formatCSV:
+		writer.csv = csv.NewWriter(w)
+		err := writer.csv.Write([]string{"timestamp", "text", "tags"})
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown output format %q", format)
+	}
+	return writer, nil
+}
+
+// newTemplateWriter writes every entry through the template, followed by a newline
+func newTemplateWriter(w io.Writer, text string) (*entryWriter, error) {
+	tmpl, err := parseEntryTemplate(text)
+	if err != nil {
+		return nil, err
+	}
+	return &entryWriter{w: w, format: formatTemplate, tmpl: tmpl}, nil
+}
+
+func (e *entryWriter) Write(entries []tinyhatchet.LogEntry) error {
+	for _, entry := range entries {
+		var err error
+		switch e.format {
+		case formatJSONL:
+			entry.Timestamp = entry.Timestamp.In(displayZone)
+			err = e.json.Encode(entry)
+		case formatCSV:
+			err = e.csv.Write([]string{entry.Timestamp.In(displayZone).Format(time.RFC3339Nano), entry.Text, strings.Join(entry.Tags, ",")})
+		case formatText:
+			_, err = fmt.Fprintf(e.w, "%s [%s] %s\n", entry.Timestamp.In(displayZone).Format(time.RFC3339), strings.Join(entry.Tags, ","), entry.Text)
+		case formatTemplate:
+			entry.Timestamp = entry.Timestamp.In(displayZone)
+			err = e.tmpl.Execute(e.w, entry)
+			if err == nil {
+				_, err = io.WriteString(e.w, "\n")
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *entryWriter) Flush() error {
+	if e.csv == nil {
+		return nil
+	}
+	e.csv.Flush()
+	return e.csv.Error()
+}
+
+// exportEntries writes the entries to a new file at path, which is only readable by the user.
+// It never overwrites an existing file.
+func exportEntries(path string, format outputFormat, entries []tinyhatchet.LogEntry) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if os.IsExist(err) {
+		return fmt.Errorf("%s already exists, choose another file name", path)
+	}
+	if err != nil {
+		return fmt.Errorf("create export file: %w", err)
+	}
+	err = writeEntries(f, format, entries)
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("write export file: %w", err)
+	}
+	return f.Close()
+}
+
+type exported struct {
+	path  string
+	count int
+}
+
+// exportPrompt asks for the file the search results are exported to.
+// Tab cycles the format and keeps the file extension in step with it.
+// A file that already exists is reported in the prompt, which stays open.
+type exportPrompt struct {
+	active bool
+	format outputFormat
+	input  textinput.Model
+	err    error
+}
+
+func newExportPrompt() exportPrompt {
+	p := exportPrompt{active: true, format: formatJSONL}
+	p.input = textinput.NewModel()
+	p.input.CursorStyle = cursorStyle
+	p.input.Prompt = "Export to > "
+	p.input.PromptStyle = focusedStyle
+	p.input.TextStyle = focusedStyle
+	p.input.SetValue("tinyhatchet-" + time.Now().Format("20060102-150405") + p.format.extension())
+	p.input.Focus()
+	return p
+}
+
+func (p *exportPrompt) nextFormat() {
+	next := outputFormats[0]
+	for i, format := range outputFormats {
+		if format == p.format && i < len(outputFormats)-1 {
+			next = outputFormats[i+1]
+		}
+	}
+	path := p.input.Value()
+	if filepath.Ext(path) == p.format.extension() {
+		p.input.SetValue(strings.TrimSuffix(path, p.format.extension()) + next.extension())
+		p.input.CursorEnd()
+	}
+	p.format = next
+}
+
+func (p exportPrompt) View() string {
+	if p.err != nil {
+		return fmt.Sprintf("%s %s", p.input.View(), errorStyle.Render(p.err.Error()))
+	}
+	return fmt.Sprintf("%s %s", p.input.View(), blurredStyle.Render("("+string(p.format)+", tab to change)"))
+}
+
+// updateExport handles input while the export prompt is open
+func (s *searchMenu) updateExport(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "ctrl+c":
+		return tea.Quit
+	case "esc":
+		s.export.active = false
+		s.layout()
+		return nil
+	case "tab":
+		s.export.nextFormat()
+		return nil
+	case "enter":
+		path := s.export.input.Value()
+		if _, err := os.Stat(path); err == nil {
+			s.export.err = fmt.Errorf("%s already exists, choose another file name", path)
+			return nil
+		}
+		s.export.active = false
+		s.layout()
+		format, entries := s.export.format, entriesOf(s.list.VisibleItems())
+		return func() tea.Msg {
+			err := exportEntries(path, format, entries)
+			if err != nil {
+				return err
+			}
+			return exported{path: path, count: len(entries)}
+		}
+	}
+	var cmd tea.Cmd
+	s.export.err = nil
+	s.export.input, cmd = s.export.input.Update(msg)
+	return cmd
+}
+
+func entriesOf(items []list.Item) []tinyhatchet.LogEntry {
+	entries := make([]tinyhatchet.LogEntry, 0, len(items))
+	for _, i := range items {
+		if entry, ok := i.(item); ok {
+			entries = append(entries, entry.LogEntry)
+		}
+	}
+	return entries
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/TinyHatchet/client/tinyhatchet"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// facetWidth is the width of the tag panel beside the result list, gap included
+const facetWidth = 30
+
+type facetState int
+
+const (
+	facetInclude facetState = iota + 1
+	facetExclude
+)
+
+type tagCount struct {
+	tag   string
+	count int
+}
+
+// facetPanel counts the tags of the loaded entries, most used first.
+// Entries are shown when they have any included tag, or when no tag is
+// included, and have none of the excluded ones.
+type facetPanel struct {
+	visible bool
+	focused bool
+	tags    []tagCount
+	cursor  int
+	states  map[string]facetState
+}
+
+func (p facetPanel) active() bool {
+	return len(p.states) > 0
+}
+
+func (p facetPanel) match(entry tinyhatchet.LogEntry) bool {
+	if len(p.states) == 0 {
+		return true
+	}
+	included, wantsInclude := false, false
+	for _, state := range p.states {
+		if state == facetInclude {
+			wantsInclude = true
+			break
+		}
+	}
+	for _, tag := range entry.Tags {
+		switch p.states[tag] {
+		case facetExclude:
+			return false
+		case facetInclude:
+			included = true
+		}
+	}
+	return included || !wantsInclude
+}
+
+// count recounts the tags of the entries, keeping the cursor on the same tag
+func (p *facetPanel) count(entries []tinyhatchet.LogEntry) {
+	var selected string
+	if p.cursor < len(p.tags) {
+		selected = p.tags[p.cursor].tag
+	}
+	counts := map[string]int{}
+	for _, entry := range entries {
+		for _, tag := range entry.Tags {
+			counts[tag]++
+		}
+	}
+	p.tags = make([]tagCount, 0, len(counts))
+	for tag, count := range counts {
+		p.tags = append(p.tags, tagCount{tag: tag, count: count})
+	}
+	sort.Slice(p.tags, func(i, j int) bool {
+		if p.tags[i].count != p.tags[j].count {
+			return p.tags[i].count > p.tags[j].count
+		}
+		return p.tags[i].tag < p.tags[j].tag
+	})
+	p.cursor = 0
+	for i, t := range p.tags {
+		if t.tag == selected {
+			p.cursor = i
+		}
+	}
+}
+
+// toggle switches the tag under the cursor to state, or back to neither when it already was
+func (p *facetPanel) toggle(state facetState) {
+	if p.cursor >= len(p.tags) {
+		return
+	}
+	if p.states == nil {
+		p.states = map[string]facetState{}
+	}
+	tag := p.tags[p.cursor].tag
+	if p.states[tag] == state {
+		delete(p.states, tag)
+		return
+	}
+	p.states[tag] = state
+}
+
+// updateFacets handles input while the tag panel has the focus
+func (s *searchMenu) updateFacets(msg tea.KeyMsg) tea.Cmd {
+	p := &s.facets
+	switch msg.String() {
+	case "ctrl+c":
+		return tea.Quit
+	case "esc", "tab":
+		p.focused = false
+	case "t":
+		p.visible, p.focused = false, false
+		s.layout()
+	case "up", "k":
+		if p.cursor > 0 {
+			p.cursor--
+		}
+	case "down", "j":
+		if p.cursor < len(p.tags)-1 {
+			p.cursor++
+		}
+	case "enter", " ", "+":
+		p.toggle(facetInclude)
+		return s.showEntries()
+	case "-", "x":
+		p.toggle(facetExclude)
+		return s.showEntries()
+	case "c":
+		p.states = nil
+		return s.showEntries()
+	}
+	return nil
+}
+
+func (p facetPanel) View(height int) string {
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("Tags"))
+	b.WriteString("\n\n")
+
+	// title, blank line, blank line and two lines of help
+	rows := height - 5
+	if rows < 1 {
+		rows = 1
+	}
+	first := 0
+	if p.cursor >= rows {
+		first = p.cursor - rows + 1
+	}
+	if len(p.tags) == 0 {
+		b.WriteString(blurredStyle.Render("No tags"))
+		b.WriteRune('\n')
+	}
+	for i := first; i < len(p.tags) && i < first+rows; i++ {
+		t := p.tags[i]
+		cursor := " "
+		if p.focused && i == p.cursor {
+			cursor = ">"
+		}
+		marker, style := " ", noStyle
+		switch p.states[t.tag] {
+		case facetInclude:
+			marker, style = "+", focusedStyle
+		case facetExclude:
+			marker, style = "-", blurredStyle.Copy().Strikethrough(true)
+		}
+		tag := []rune(t.tag)
+		if len(tag) > facetWidth-12 {
+			tag = append(tag[:facetWidth-13], '…')
+		}
+		fmt.Fprintf(&b, "%s%s %5d %s\n", cursor, marker, t.count, style.Render(string(tag)))
+	}
+
+	b.WriteRune('\n')
+	if p.focused {
+		b.WriteString(blurredStyle.Render("enter include, - exclude\nc clear, tab back to list"))
+	} else {
+		b.WriteString(blurredStyle.Render("tab to select tags"))
+	}
+	return lipgloss.NewStyle().Width(facetWidth - 2).MaxHeight(height).MarginLeft(2).Render(b.String())
+}
@@ -0,0 +1,386 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/TinyHatchet/client/tinyhatchet"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// filter selects loaded entries on the client, without asking the server again.
+//
+// The query language accepts words and "quoted phrases" matched case
+// insensitively against the text, tag:name to match a tag, /regexp/ matched
+// against the text, and the operators AND, OR and NOT with parentheses.
+// Terms next to each other are joined with AND, which binds tighter than OR.
+type filter interface {
+	match(entry tinyhatchet.LogEntry) bool
+}
+
+type andFilter []filter
+
+func (f andFilter) match(entry tinyhatchet.LogEntry) bool {
+	for _, sub := range f {
+		if !sub.match(entry) {
+			return false
+		}
+	}
+	return true
+}
+
+type orFilter []filter
+
+func (f orFilter) match(entry tinyhatchet.LogEntry) bool {
+	for _, sub := range f {
+		if sub.match(entry) {
+			return true
+		}
+	}
+	return false
+}
+
+type notFilter struct {
+	filter
+}
+
+func (f notFilter) match(entry tinyhatchet.LogEntry) bool {
+	return !f.filter.match(entry)
+}
+
+// textFilter holds the lower cased word or phrase
+type textFilter string
+
+func (f textFilter) match(entry tinyhatchet.LogEntry) bool {
+	return strings.Contains(strings.ToLower(entry.Text), string(f))
+}
+
+type tagFilter string
+
+func (f tagFilter) match(entry tinyhatchet.LogEntry) bool {
+	for _, tag := range entry.Tags {
+		if strings.EqualFold(tag, string(f)) {
+			return true
+		}
+	}
+	return false
+}
+
+type regexpFilter struct {
+	*regexp.Regexp
+}
+
+func (f regexpFilter) match(entry tinyhatchet.LogEntry) bool {
+	return f.MatchString(entry.Text)
+}
+
+func filterEntries(entries []tinyhatchet.LogEntry, f filter) []tinyhatchet.LogEntry {
+	matched := make([]tinyhatchet.LogEntry, 0, len(entries))
+	for _, entry := range entries {
+		if f.match(entry) {
+			matched = append(matched, entry)
+		}
+	}
+	return matched
+}
+
+type filterTokenKind int
+
+const (
+	tokenWord filterTokenKind = iota
+	tokenPhrase
+	tokenTag
+	tokenRegexp
+	tokenAnd
+	tokenOr
+	tokenNot
+	tokenOpen
+	tokenClose
+)
+
+type filterToken struct {
+	kind  filterTokenKind
+	value string
+}
+
+// parseFilter parses the query language, returning nil for an empty query
+func parseFilter(query string) (filter, error) {
+	tokens, err := tokenizeFilter(query)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+	p := filterParser{tokens: tokens}
+	f, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q", p.tokens[p.pos].value)
+	}
+	return f, nil
+}
+
+func tokenizeFilter(query string) ([]filterToken, error) {
+	tokens := []filterToken{}
+	runes := []rune(query)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, filterToken{kind: tokenOpen, value: "("})
+			i++
+		case r == ')':
+			tokens = append(tokens, filterToken{kind: tokenClose, value: ")"})
+			i++
+		case r == '"':
+			value, next, err := readDelimited(runes, i, '"')
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, filterToken{kind: tokenPhrase, value: value})
+			i = next
+		case r == '/':
+			value, next, err := readDelimited(runes, i, '/')
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, filterToken{kind: tokenRegexp, value: value})
+			i = next
+		default:
+			start := i
+			for i < len(runes) && !unicode.IsSpace(runes[i]) && runes[i] != '(' && runes[i] != ')' {
+				if runes[i] == '"' && i > start {
+					// tag:"name with spaces"
+					value, next, err := readDelimited(runes, i, '"')
+					if err != nil {
+						return nil, err
+					}
+					runes = append(append(runes[:i:i], []rune(value)...), runes[next:]...)
+					i += len([]rune(value))
+					continue
+				}
+				i++
+			}
+			word := string(runes[start:i])
+			switch {
+			case word == "AND":
+				tokens = append(tokens, filterToken{kind: tokenAnd, value: word})
+			case word == "OR":
+				tokens = append(tokens, filterToken{kind: tokenOr, value: word})
+			case word == "NOT":
+				tokens = append(tokens, filterToken{kind: tokenNot, value: word})
+			case strings.HasPrefix(word, "tag:"):
+				tokens = append(tokens, filterToken{kind: tokenTag, value: strings.TrimPrefix(word, "tag:")})
+			default:
+				tokens = append(tokens, filterToken{kind: tokenWord, value: word})
+			}
+		}
+	}
+	return tokens, nil
+}
+
+// readDelimited reads from the opening delimiter at start to the closing one,
+// unescaping \delim, and returns the index after the closing delimiter
+func readDelimited(runes []rune, start int, delim rune) (string, int, error) {
+	var b strings.Builder
+	for i := start + 1; i < len(runes); i++ {
+		if runes[i] == '\\' && i+1 < len(runes) && runes[i+1] == delim {
+			b.WriteRune(delim)
+			i++
+			continue
+		}
+		if runes[i] == delim {
+			return b.String(), i + 1, nil
+		}
+		b.WriteRune(runes[i])
+	}
+	return "", 0, fmt.Errorf("missing closing %c", delim)
+}
+
+type filterParser struct {
+	tokens []filterToken
+	pos    int
+}
+
+func (p *filterParser) peek() (filterToken, bool) {
+	if p.pos >= len(p.tokens) {
+		return filterToken{}, false
+	}
+	return p.tokens[p.pos], true
+}
+
+func (p *filterParser) parseOr() (filter, error) {
+	f, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	or := orFilter{f}
+	for {
+		t, ok := p.peek()
+		if !ok || t.kind != tokenOr {
+			break
+		}
+		p.pos++
+		f, err = p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		or = append(or, f)
+	}
+	if len(or) == 1 {
+		return or[0], nil
+	}
+	return or, nil
+}
+
+func (p *filterParser) parseAnd() (filter, error) {
+	f, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	and := andFilter{f}
+	for {
+		t, ok := p.peek()
+		if !ok || t.kind == tokenOr || t.kind == tokenClose {
+			break
+		}
+		if t.kind == tokenAnd {
+			p.pos++
+		}
+		f, err = p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		and = append(and, f)
+	}
+	if len(and) == 1 {
+		return and[0], nil
+	}
+	return and, nil
+}
+
+func (p *filterParser) parseNot() (filter, error) {
+	t, ok := p.peek()
+	if ok && t.kind == tokenNot {
+		p.pos++
+		f, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return notFilter{f}, nil
+	}
+	return p.parseTerm()
+}
+
+func (p *filterParser) parseTerm() (filter, error) {
+	t, ok := p.peek()
+	if !ok {
+		return nil, fmt.Errorf("unexpected end of query")
+	}
+	p.pos++
+	switch t.kind {
+	case tokenOpen:
+		f, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		closing, ok := p.peek()
+		if !ok || closing.kind != tokenClose {
+			return nil, fmt.Errorf("missing closing )")
+		}
+		p.pos++
+		return f, nil
+	case tokenWord, tokenPhrase:
+		return textFilter(strings.ToLower(t.value)), nil
+	case tokenTag:
+		if t.value == "" {
+			return nil, fmt.Errorf("tag: needs a tag name")
+		}
+		return tagFilter(t.value), nil
+	case tokenRegexp:
+		re, err := regexp.Compile(t.value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regexp /%s/: %w", t.value, err)
+		}
+		return regexpFilter{re}, nil
+	}
+	return nil, fmt.Errorf("unexpected %q", t.value)
+}
+
+// filterPrompt edits the filter over the loaded search results.
+// The filter applies while typing, a query that does not parse keeps the last one that did.
+type filterPrompt struct {
+	active bool
+	input  textinput.Model
+	filter filter
+	err    error
+}
+
+func (p *filterPrompt) open() {
+	if p.input.Prompt == "" {
+		p.input = textinput.NewModel()
+		p.input.CursorStyle = cursorStyle
+		p.input.Prompt = "Filter > "
+		p.input.PromptStyle = focusedStyle
+		p.input.TextStyle = focusedStyle
+		p.input.Placeholder = `error AND NOT tag:debug, "a phrase", /regexp/`
+	}
+	p.active = true
+	p.input.CursorEnd()
+	p.input.Focus()
+}
+
+func (p filterPrompt) match(entry tinyhatchet.LogEntry) bool {
+	return p.filter == nil || p.filter.match(entry)
+}
+
+func (p filterPrompt) View() string {
+	if p.err != nil {
+		return p.input.View() + " " + errorStyle.Render(p.err.Error())
+	}
+	return p.input.View() + " " + blurredStyle.Render("(enter to keep, esc to clear)")
+}
+
+// updateFilter handles input while the filter prompt is open
+func (s *searchMenu) updateFilter(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "ctrl+c":
+		return tea.Quit
+	case "esc":
+		s.filter.active = false
+		s.filter.input.SetValue("")
+		s.filter.filter = nil
+		s.filter.err = nil
+		s.layout()
+		return s.showEntries()
+	case "enter":
+		s.filter.active = false
+		s.filter.input.Blur()
+		s.layout()
+		if s.filter.err != nil {
+			return s.list.NewStatusMessage(errorStyle.Render("Filter not applied: " + s.filter.err.Error()))
+		}
+		return nil
+	}
+	previous := s.filter.input.Value()
+	var cmd tea.Cmd
+	s.filter.input, cmd = s.filter.input.Update(msg)
+	if s.filter.input.Value() == previous {
+		return cmd
+	}
+	f, err := parseFilter(s.filter.input.Value())
+	s.filter.err = err
+	if err != nil {
+		return cmd
+	}
+	s.filter.filter = f
+	return tea.Batch(cmd, s.showEntries())
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/TinyHatchet/client/tinyhatchet"
+)
+
+func TestParseFilter(t *testing.T) {
+	entries := map[string]tinyhatchet.LogEntry{
+		"db error":    {Text: "connection to db failed: timeout", Tags: []string{"db", "error"}},
+		"db slow":     {Text: "slow query on users table", Tags: []string{"db"}},
+		"http error":  {Text: "GET /users returned 500", Tags: []string{"http", "error"}},
+		"http ok":     {Text: "GET /health returned 200", Tags: []string{"http"}},
+		"worker tags": {Text: "job 42 done", Tags: []string{"job runner"}},
+	}
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"timeout", []string{"db error"}},
+		{"TIMEOUT", []string{"db error"}},
+		{"get returned", []string{"http error", "http ok"}},
+		{"get AND 500", []string{"http error"}},
+		{"get 500", []string{"http error"}},
+		{"slow OR 500", []string{"db slow", "http error"}},
+		{"users OR timeout db", []string{"db error", "db slow", "http error"}},
+		{"timeout OR slow query", []string{"db error", "db slow"}},
+		{"(timeout OR slow) query", []string{"db slow"}},
+		{"NOT get", []string{"db error", "db slow", "worker tags"}},
+		{"NOT get returned", []string{}},
+		{"NOT (get OR job)", []string{"db error", "db slow"}},
+		{`"returned 200"`, []string{"http ok"}},
+		{`"returned   200"`, []string{}},
+		{`"query on"`, []string{"db slow"}},
+		{"tag:db", []string{"db error", "db slow"}},
+		{"tag:ERROR", []string{"db error", "http error"}},
+		{"tag:db NOT tag:error", []string{"db slow"}},
+		{`tag:"job runner"`, []string{"worker tags"}},
+		{"tag:job", []string{}},
+		{`/returned \d00$/`, []string{"http error", "http ok"}},
+		{`/^GET .*5\d\d/`, []string{"http error"}},
+		{`/a\/b/`, []string{}},
+		{"/job|slow/ tag:db", []string{"db slow"}},
+	}
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			f, err := parseFilter(test.query)
+			if err != nil {
+				t.Fatalf("parseFilter: %v", err)
+			}
+			got := map[string]bool{}
+			for name, entry := range entries {
+				if f.match(entry) {
+					got[name] = true
+				}
+			}
+			if len(got) != len(test.want) {
+				t.Errorf("matched %v, want %v", got, test.want)
+			}
+			for _, name := range test.want {
+				if !got[name] {
+					t.Errorf("matched %v, want %v", got, test.want)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestParseFilterErrors(t *testing.T) {
+	tests := []string{
+		`"unclosed phrase`,
+		"/unclosed regexp",
+		"/[/",
+		"(timeout",
+		"timeout)",
+		"timeout OR",
+		"NOT",
+		"AND timeout",
+	}
+	for _, query := range tests {
+		t.Run(query, func(t *testing.T) {
+			_, err := parseFilter(query)
+			if err == nil {
+				t.Errorf("parseFilter(%q) succeeded, want an error", query)
+			}
+		})
+	}
+}
+
+func TestParseFilterEmpty(t *testing.T) {
+	for _, query := range []string{"", "   "} {
+		f, err := parseFilter(query)
+		if f != nil || err != nil {
+			t.Errorf("parseFilter(%q) = %v, %v, want nil, nil", query, f, err)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/TinyHatchet/client/tinyhatchet"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	followInterval = 5 * time.Second
+	// followLimit is how many entries a poll asks for, a busy tail catches up over several polls
+	followLimit = pageSize
+)
+
+// follower tracks the state of a live tail over the current search results.
+// Every toggle gets a new id so that polls started by an earlier toggle are dropped.
+// While pages of the search are left to load, from is where the search ended
+// and the tail starts there, the pages fill in the rest.
+type follower struct {
+	id        int
+	following bool
+	newest    time.Time
+	from      time.Time
+	seen      map[string]struct{}
+}
+
+type followTick struct {
+	id int
+}
+
+type followedEntries struct {
+	id      int
+	entries []tinyhatchet.LogEntry
+	// full is set when the poll returned followLimit entries and more may be waiting
+	full bool
+}
+
+type followError struct {
+	id  int
+	err error
+}
+
+func (f *follower) reset() {
+	f.newest, f.from = time.Time{}, time.Time{}
+	f.seen = map[string]struct{}{}
+}
+
+func (f follower) active(id int) bool {
+	return f.following && f.id == id
+}
+
+// see records the entry and reports whether it had not been seen before
+func (f *follower) see(entry tinyhatchet.LogEntry) bool {
+	if f.seen == nil {
+		f.seen = map[string]struct{}{}
+	}
+	key := entryKey(entry)
+	if _, ok := f.seen[key]; ok {
+		return false
+	}
+	f.seen[key] = struct{}{}
+	if entry.Timestamp.After(f.newest) {
+		f.newest = entry.Timestamp
+	}
+	return true
+}
+
+func entryKey(entry tinyhatchet.LogEntry) string {
+	return strconv.FormatInt(entry.Timestamp.UnixNano(), 10) + "\x00" + strings.Join(entry.Tags, ",") + "\x00" + entry.Text
+}
+
+func followAfter(id int) tea.Cmd {
+	return tea.Tick(followInterval, func(time.Time) tea.Msg {
+		return followTick{id: id}
+	})
+}
+
+func (s *searchMenu) toggleFollow() tea.Cmd {
+	if s.follow.following {
+		s.stopFollowing()
+		return nil
+	}
+	s.follow.id++
+	s.follow.following = true
+	s.follow.from = time.Time{}
+	if s.pages.next != nil || s.pages.loading {
+		s.follow.from = s.pages.started
+		if end, err := time.Parse(time.RFC3339Nano, s.lastQuery.End); err == nil {
+			s.follow.from = end
+		}
+	}
+	s.list.Title = s.resultTitle()
+	return s.pollEntries
+}
+
+func (s *searchMenu) stopFollowing() {
+	s.follow.id++
+	s.follow.following = false
+	s.list.Title = s.resultTitle()
+}
+
+// pollEntries asks for the oldest followLimit entries since the newest entry seen
+// so far, or since from when that is later. The end of the original range is
+// dropped so the tail keeps moving forward.
+func (s searchMenu) pollEntries() tea.Msg {
+	query := s.lastQuery
+	query.End = ""
+	query.Order = tinyhatchet.OrderAscending
+	query.Limit, query.Offset = followLimit, 0
+	start := s.follow.newest
+	if s.follow.from.After(start) {
+		start = s.follow.from
+	}
+	if !start.IsZero() {
+		query.Start = start.Format(time.RFC3339Nano)
+	}
+	entries, err := client.SearchEntries(context.Background(), query)
+	if err != nil {
+		return followError{id: s.follow.id, err: err}
+	}
+	return followedEntries{id: s.follow.id, entries: entries, full: len(entries) >= followLimit}
+}
+
+// appendEntries adds the entries that are not loaded yet after the others.
+// With keepAtEnd the cursor moves along if it was on the newest item,
+// which is the first one when the newest entries are shown first. Sorted by
+// tag or length the newest entries have no fixed place, so the cursor stays.
+func (s *searchMenu) appendEntries(entries []tinyhatchet.LogEntry, keepAtEnd bool) tea.Cmd {
+	items := s.list.Items()
+	newest := len(items) - 1
+	if s.sort == sortNewest {
+		newest = 0
+	}
+	timeSorted := s.sort == sortOldest || s.sort == sortNewest
+	atEnd := keepAtEnd && timeSorted && (len(items) == 0 || s.list.Index() == newest)
+	added := false
+	for _, entry := range entries {
+		if s.follow.see(entry) {
+			s.entries = append(s.entries, entry)
+			added = true
+		}
+	}
+	if !added {
+		return nil
+	}
+	cmd := s.showEntries()
+	if atEnd && s.sort == sortNewest {
+		s.list.Select(0)
+	} else if atEnd {
+		s.list.Select(len(s.list.Items()) - 1)
+	}
+	return cmd
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/TinyHatchet/client/tinyhatchet"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	// histogramHeight is the number of lines the histogram takes above the result list
+	histogramHeight = 2
+	maxBuckets      = 120
+)
+
+// bucketIntervals are the bucket sizes the histogram chooses from, the smallest that fits wins
+var bucketIntervals = []time.Duration{
+	time.Second, 5 * time.Second, 10 * time.Second, 30 * time.Second,
+	time.Minute, 5 * time.Minute, 10 * time.Minute, 15 * time.Minute, 30 * time.Minute,
+	time.Hour, 3 * time.Hour, 6 * time.Hour, 12 * time.Hour,
+	24 * time.Hour, 7 * 24 * time.Hour, 30 * 24 * time.Hour,
+}
+
+var sparkBars = []rune("▁▂▃▄▅▆▇█")
+
+// histogram counts the shown entries per time bucket over the searched range
+type histogram struct {
+	start    time.Time
+	interval time.Duration
+	counts   []int
+	cursor   int
+}
+
+// newHistogram buckets the entries between start and end, either of which may be zero,
+// widening the range to include every entry and using at most buckets buckets
+func newHistogram(entries []tinyhatchet.LogEntry, start, end time.Time, buckets int) histogram {
+	for _, entry := range entries {
+		if start.IsZero() || entry.Timestamp.Before(start) {
+			start = entry.Timestamp
+		}
+		if end.IsZero() || entry.Timestamp.After(end) {
+			end = entry.Timestamp
+		}
+	}
+	if start.IsZero() || end.IsZero() || buckets < 1 {
+		return histogram{}
+	}
+
+	h := histogram{}
+	for _, interval := range bucketIntervals {
+		if bucketCount(start, end, interval) <= buckets {
+			h.interval = interval
+			break
+		}
+	}
+	if h.interval == 0 {
+		// too long a range for every interval, widen whole days until it fits
+		day := 24 * time.Hour
+		h.interval = (end.Sub(start)/time.Duration(buckets)/day + 1) * day
+		for bucketCount(start, end, h.interval) > buckets {
+			h.interval += day
+		}
+	}
+	h.start = truncateIn(start, h.interval, displayZone)
+	h.counts = make([]int, bucketCount(start, end, h.interval))
+	for _, entry := range entries {
+		h.counts[h.bucket(entry.Timestamp)]++
+	}
+	return h
+}
+
+// bucketCount is how many buckets of interval it takes to cover start to end
+func bucketCount(start, end time.Time, interval time.Duration) int {
+	return int(end.Sub(truncateIn(start, interval, displayZone))/interval) + 1
+}
+
+// truncateIn rounds t down to a multiple of d counted in loc, so that hour and day
+// buckets start on the hour and at midnight there rather than in UTC
+func truncateIn(t time.Time, d time.Duration, loc *time.Location) time.Time {
+	_, offset := t.In(loc).Zone()
+	shift := time.Duration(offset) * time.Second
+	return t.Add(shift).Truncate(d).Add(-shift)
+}
+
+func (h histogram) bucket(t time.Time) int {
+	i := int(t.Sub(h.start) / h.interval)
+	if i < 0 {
+		return 0
+	}
+	if i >= len(h.counts) {
+		return len(h.counts) - 1
+	}
+	return i
+}
+
+// bucketRange returns the start and end of the bucket under the cursor
+func (h histogram) bucketRange() (time.Time, time.Time) {
+	start := h.start.Add(time.Duration(h.cursor) * h.interval)
+	return start, start.Add(h.interval)
+}
+
+func (h *histogram) move(by int) {
+	h.cursor += by
+	if h.cursor < 0 {
+		h.cursor = 0
+	}
+	if h.cursor >= len(h.counts) {
+		h.cursor = len(h.counts) - 1
+	}
+}
+
+func (h histogram) View() string {
+	if len(h.counts) == 0 {
+		return blurredStyle.Render("No entries to chart") + "\n"
+	}
+	most := 0
+	for _, count := range h.counts {
+		if count > most {
+			most = count
+		}
+	}
+
+	var b strings.Builder
+	for i, count := range h.counts {
+		bar := " "
+		if count > 0 {
+			bar = string(sparkBars[(count*(len(sparkBars)-1)+most-1)/most])
+		}
+		if i == h.cursor {
+			if count == 0 {
+				bar = string(sparkBars[0])
+			}
+			bar = focusedStyle.Render(bar)
+		}
+		b.WriteString(bar)
+	}
+	b.WriteRune('\n')
+
+	start, end := h.bucketRange()
+	layout := bucketLayout(h.interval)
+	b.WriteString(fmt.Sprintf("%s – %s  %d entries", start.In(displayZone).Format(layout), end.In(displayZone).Format(layout), h.counts[h.cursor]))
+	b.WriteString(blurredStyle.Render(fmt.Sprintf("  (%s buckets, [/] move, z zoom, Z back)", shortDuration(h.interval))))
+	return lipgloss.NewStyle().MaxWidth(width-2*horizMargin).Render(b.String()) + "\n"
+}
+
+// bucketLayout shows as much of the time as the bucket size needs
+func bucketLayout(interval time.Duration) string {
+	switch {
+	case interval < time.Minute:
+		return "15:04:05"
+	case interval < 24*time.Hour:
+		return "Jan 2 15:04"
+	}
+	return "2006-01-02"
+}
+
+func shortDuration(d time.Duration) string {
+	switch {
+	case d%(24*time.Hour) == 0:
+		return fmt.Sprintf("%dd", d/(24*time.Hour))
+	case d%time.Hour == 0:
+		return fmt.Sprintf("%dh", d/time.Hour)
+	case d%time.Minute == 0:
+		return fmt.Sprintf("%dm", d/time.Minute)
+	}
+	return d.String()
+}
+
+// chart rebuilds the histogram from the shown entries, keeping the cursor where it was
+func (s *searchMenu) chart(items []list.Item) {
+	start, _ := time.Parse(time.RFC3339Nano, s.lastQuery.Start)
+	end, _ := time.Parse(time.RFC3339Nano, s.lastQuery.End)
+	_, right, _, left := docStyle.GetMargin()
+	buckets := width - left - right
+	if buckets > maxBuckets {
+		buckets = maxBuckets
+	}
+	cursor := s.timeline.cursor
+	s.timeline = newHistogram(entriesOf(items), start, end, buckets)
+	s.timeline.cursor = cursor
+	s.timeline.move(0)
+}
+
+// moveBucket moves the histogram cursor and selects the first entry of the bucket
+func (s *searchMenu) moveBucket(by int) {
+	s.timeline.move(by)
+	if len(s.timeline.counts) == 0 {
+		return
+	}
+	start, end := s.timeline.bucketRange()
+	for i, listItem := range s.list.Items() {
+		entry, ok := listItem.(item)
+		if ok && !entry.Timestamp.Before(start) && entry.Timestamp.Before(end) {
+			s.list.Select(i)
+			return
+		}
+	}
+}
+
+// zoom searches again over the range of the bucket under the cursor, remembering
+// the inputs so that zoomOut can go back to them
+func (s *searchMenu) zoom() tea.Cmd {
+	if len(s.timeline.counts) == 0 {
+		return nil
+	}
+	start, end := s.timeline.bucketRange()
+	inputs := s.savedSearch()
+	s.zoomed = append(s.zoomed, inputs)
+	inputs.Start = start.In(displayZone).Format(time.RFC3339)
+	inputs.End = end.In(displayZone).Format(time.RFC3339)
+	return s.rerun(inputs)
+}
+
+func (s *searchMenu) zoomOut() tea.Cmd {
+	if len(s.zoomed) == 0 {
+		return s.list.NewStatusMessage("Not zoomed in")
+	}
+	inputs := s.zoomed[len(s.zoomed)-1]
+	s.zoomed = s.zoomed[:len(s.zoomed)-1]
+	return s.rerun(inputs)
+}
+
+// rerun searches again with the inputs, staying on the result view.
+// Zooming is not a new search, so the history is left alone.
+func (s *searchMenu) rerun(inputs SavedSearch) tea.Cmd {
+	s.stopFollowing()
+	s.setInputs(inputs)
+	s.timeline.cursor = 0
+	return s.run()
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/TinyHatchet/client/tinyhatchet"
+)
+
+func TestNewHistogramFits(t *testing.T) {
+	end := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		start time.Time
+	}{
+		{"a minute", end.Add(-time.Minute)},
+		{"a day", end.Add(-24 * time.Hour)},
+		{"a year", end.AddDate(-1, 0, 0)},
+		{"since the epoch", time.Unix(0, 0)},
+	}
+	for _, test := range tests {
+		for _, buckets := range []int{1, 7, 100, maxBuckets} {
+			h := newHistogram([]tinyhatchet.LogEntry{{Timestamp: end}}, test.start, end, buckets)
+			if len(h.counts) < 1 || len(h.counts) > buckets {
+				t.Errorf("%s in %d buckets: got %d buckets of %v", test.name, buckets, len(h.counts), h.interval)
+			}
+			if h.counts[h.bucket(end)] != 1 {
+				t.Errorf("%s in %d buckets: entry not counted", test.name, buckets)
+			}
+		}
+	}
+}
@@ -0,0 +1,281 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/sahilm/fuzzy"
+)
+
+const (
+	// maxHistory is how many searches are kept, the oldest are dropped first
+	maxHistory = 100
+	// historyFinderResults is how many matches the history finder shows
+	historyFinderResults = 8
+)
+
+var historyPath string
+
+// historyWrites serializes writing the history file. Every recorded search
+// takes the next version, and a write older than the last one is skipped,
+// so the file always ends up with the latest history.
+var historyWrites struct {
+	sync.Mutex
+	version, written int
+}
+
+var findHistoryKey = key.NewBinding(key.WithKeys("ctrl+r"), key.WithHelp("ctrl+r", "find in history"))
+
+// searchHistory holds the searches run before, oldest first.
+// While browsing with up and down, index is the entry shown in the inputs and
+// draft holds what was typed before; index is len(entries) otherwise.
+type searchHistory struct {
+	entries []SavedSearch
+	index   int
+	draft   SavedSearch
+	finder  historyFinder
+}
+
+func newSearchHistory(entries []SavedSearch) searchHistory {
+	return searchHistory{entries: entries, index: len(entries)}
+}
+
+func (h searchHistory) browsing() bool {
+	return h.index < len(h.entries)
+}
+
+// loadHistory reads the history file, skipping lines it does not understand
+func loadHistory(path string) []SavedSearch {
+	body, err := ioutil.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Println(fmt.Errorf("read history file: %w", err))
+		}
+		return nil
+	}
+	entries := []SavedSearch{}
+	for _, line := range bytes.Split(body, []byte("\n")) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		var entry SavedSearch
+		if json.Unmarshal(line, &entry) == nil {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// writeHistory replaces the history file with the entries, one JSON object per line
+func writeHistory(path string, entries []SavedSearch) error {
+	var b bytes.Buffer
+	encoder := json.NewEncoder(&b)
+	for _, entry := range entries {
+		err := encoder.Encode(entry)
+		if err != nil {
+			return fmt.Errorf("marshal history: %w", err)
+		}
+	}
+	err := ioutil.WriteFile(path, b.Bytes(), 0600)
+	if err != nil {
+		return fmt.Errorf("write history file: %w", err)
+	}
+	return nil
+}
+
+// addHistory returns a new history ending with search, which is moved there
+// if it was run before, keeping at most maxHistory entries
+func addHistory(entries []SavedSearch, search SavedSearch) []SavedSearch {
+	added := make([]SavedSearch, 0, len(entries)+1)
+	for _, entry := range entries {
+		if entry != search {
+			added = append(added, entry)
+		}
+	}
+	added = append(added, search)
+	if len(added) > maxHistory {
+		added = added[len(added)-maxHistory:]
+	}
+	return added
+}
+
+// recordHistory adds the current inputs to the history and writes it out in the background
+func (s *searchMenu) recordHistory() tea.Cmd {
+	search := s.savedSearch()
+	if search == (SavedSearch{}) {
+		return nil
+	}
+	s.history = newSearchHistory(addHistory(s.history.entries, search))
+	historyWrites.Lock()
+	historyWrites.version++
+	version := historyWrites.version
+	historyWrites.Unlock()
+	path, entries := historyPath, s.history.entries
+	return func() tea.Msg {
+		historyWrites.Lock()
+		defer historyWrites.Unlock()
+		if version <= historyWrites.written {
+			return nil
+		}
+		historyWrites.written = version
+		err := writeHistory(path, entries)
+		if err != nil {
+			log.Println(err)
+		}
+		return nil
+	}
+}
+
+// recallHistory recalls earlier searches: up on the first input steps back
+// through the history, down steps forward again, ctrl+r opens the finder.
+// It reports whether the key was used.
+func (s *searchMenu) recallHistory(msg tea.KeyMsg) bool {
+	h := &s.history
+	switch {
+	case key.Matches(msg, findHistoryKey):
+		if len(h.entries) == 0 {
+			return true
+		}
+		h.finder = newHistoryFinder()
+		h.finder.search(h.entries)
+		return true
+	case msg.String() == "up" && s.focusIndex == searchStart && len(h.entries) > 0:
+		if !h.browsing() {
+			h.draft = s.savedSearch()
+		}
+		if h.index > 0 {
+			h.index--
+		}
+		s.setInputs(h.entries[h.index])
+		return true
+	case msg.String() == "down" && s.focusIndex == searchStart && h.browsing():
+		h.index++
+		if h.browsing() {
+			s.setInputs(h.entries[h.index])
+		} else {
+			s.setInputs(h.draft)
+		}
+		return true
+	}
+	return false
+}
+
+// historyFinder fuzzy finds earlier searches, the best match first
+type historyFinder struct {
+	active  bool
+	input   textinput.Model
+	matches []fuzzy.Match
+	cursor  int
+}
+
+func newHistoryFinder() historyFinder {
+	f := historyFinder{active: true}
+	f.input = textinput.NewModel()
+	f.input.CursorStyle = cursorStyle
+	f.input.Prompt = "Find in history > "
+	f.input.PromptStyle = focusedStyle
+	f.input.TextStyle = focusedStyle
+	f.input.Focus()
+	return f
+}
+
+// search matches the input against the history, newest first when the input is empty.
+// Matches index the history from the newest entry.
+func (f *historyFinder) search(entries []SavedSearch) {
+	summaries := make([]string, len(entries))
+	for i := range entries {
+		summaries[i] = entries[len(entries)-1-i].String()
+	}
+	f.cursor = 0
+	pattern := strings.TrimSpace(f.input.Value())
+	if pattern == "" {
+		f.matches = make([]fuzzy.Match, len(summaries))
+		for i, summary := range summaries {
+			f.matches[i] = fuzzy.Match{Str: summary, Index: i}
+		}
+	} else {
+		f.matches = fuzzy.Find(pattern, summaries)
+	}
+	if len(f.matches) > historyFinderResults {
+		f.matches = f.matches[:historyFinderResults]
+	}
+}
+
+// updateFinder handles input while the history finder is open
+func (s *searchMenu) updateFinder(msg tea.KeyMsg) tea.Cmd {
+	f := &s.history.finder
+	switch msg.String() {
+	case "ctrl+c":
+		return tea.Quit
+	case "esc":
+		f.active = false
+		return nil
+	case "up", "ctrl+p":
+		if f.cursor > 0 {
+			f.cursor--
+		}
+		return nil
+	case "down", "ctrl+n", "ctrl+r":
+		if f.cursor < len(f.matches)-1 {
+			f.cursor++
+		}
+		return nil
+	case "enter":
+		f.active = false
+		if len(f.matches) == 0 {
+			return nil
+		}
+		entries := s.history.entries
+		s.setInputs(entries[len(entries)-1-f.matches[f.cursor].Index])
+		s.history.index = len(entries)
+		return nil
+	}
+	var cmd tea.Cmd
+	f.input, cmd = f.input.Update(msg)
+	f.search(s.history.entries)
+	return cmd
+}
+
+func (f historyFinder) View() string {
+	var b strings.Builder
+	b.WriteString(f.input.View())
+	b.WriteRune('\n')
+	if len(f.matches) == 0 {
+		b.WriteString(blurredStyle.Render("  no matching searches"))
+		b.WriteRune('\n')
+	}
+	for i, match := range f.matches {
+		cursor := " "
+		if i == f.cursor {
+			cursor = ">"
+		}
+		fmt.Fprintf(&b, "%s %s\n", cursor, highlightMatch(match))
+	}
+	return b.String()
+}
+
+// highlightMatch renders the matched characters of the summary in the focused style
+func highlightMatch(match fuzzy.Match) string {
+	matched := make(map[int]bool, len(match.MatchedIndexes))
+	for _, i := range match.MatchedIndexes {
+		matched[i] = true
+	}
+	var b strings.Builder
+	for i, r := range match.Str {
+		if matched[i] {
+			b.WriteString(focusedStyle.Render(string(r)))
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
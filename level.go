@@ -0,0 +1,165 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/TinyHatchet/client/tinyhatchet"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/reflow/truncate"
+)
+
+// level is the severity of an entry, entries without a recognised level are levelUnknown
+type level int
+
+const (
+	levelUnknown level = iota
+	levelDebug
+	levelInfo
+	levelWarn
+	levelError
+)
+
+// levelNames maps the tags and text prefixes that name a level to it
+var levelNames = map[string]level{
+	"trace":    levelDebug,
+	"debug":    levelDebug,
+	"dbg":      levelDebug,
+	"info":     levelInfo,
+	"notice":   levelInfo,
+	"warn":     levelWarn,
+	"warning":  levelWarn,
+	"error":    levelError,
+	"err":      levelError,
+	"fatal":    levelError,
+	"critical": levelError,
+	"crit":     levelError,
+	"panic":    levelError,
+}
+
+// levelPrefix matches a level at the start of the text, like "ERROR ...", "[warn] ..." or "level=info ..."
+var levelPrefix = regexp.MustCompile(`^\s*(?:level[=:]\s*)?[\[(<]?([a-zA-Z]+)[\])>]?(?:[\s:|-]|$)`)
+
+var levelBadgeStyle = lipgloss.NewStyle().Bold(true).Width(5)
+
+var levelColors = map[level]lipgloss.TerminalColor{
+	levelDebug: lipgloss.AdaptiveColor{Light: "#A49FA5", Dark: "#777777"},
+	levelInfo:  lipgloss.AdaptiveColor{Light: "#1F7A8C", Dark: "#5FAFD7"},
+	levelWarn:  lipgloss.AdaptiveColor{Light: "#B8860B", Dark: "#FFD75F"},
+	levelError: lipgloss.AdaptiveColor{Light: "#D70000", Dark: "#FF5F5F"},
+}
+
+func (l level) String() string {
+	switch l {
+	case levelDebug:
+		return "debug"
+	case levelInfo:
+		return "info"
+	case levelWarn:
+		return "warn"
+	case levelError:
+		return "error"
+	}
+	return ""
+}
+
+// parseLevel reads a level name, an empty name is levelUnknown which filters nothing
+func parseLevel(name string) (level, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return levelUnknown, nil
+	}
+	l, ok := levelNames[name]
+	if !ok {
+		return levelUnknown, fmt.Errorf("unknown level %q, use debug, info, warn or error", name)
+	}
+	return l, nil
+}
+
+// entryLevel infers the level from the tags, like error or level:error,
+// then from a prefix of the text
+func entryLevel(entry tinyhatchet.LogEntry) level {
+	for _, tag := range entry.Tags {
+		tag = strings.ToLower(tag)
+		tag = strings.TrimPrefix(strings.TrimPrefix(tag, "level:"), "level=")
+		if l, ok := levelNames[tag]; ok {
+			return l
+		}
+	}
+	m := levelPrefix.FindStringSubmatch(entry.Text)
+	if m != nil {
+		return levelNames[strings.ToLower(m[1])]
+	}
+	return levelUnknown
+}
+
+// atLeast reports whether entries of level l pass a minimum level of min.
+// Entries without a level count as info.
+func (l level) atLeast(min level) bool {
+	if l == levelUnknown {
+		l = levelInfo
+	}
+	return l >= min
+}
+
+func filterLevel(entries []tinyhatchet.LogEntry, min level) []tinyhatchet.LogEntry {
+	matched := make([]tinyhatchet.LogEntry, 0, len(entries))
+	for _, entry := range entries {
+		if entryLevel(entry).atLeast(min) {
+			matched = append(matched, entry)
+		}
+	}
+	return matched
+}
+
+// entryDelegate renders the result list like the default delegate,
+// with a badge and a color for the level of each entry
+type entryDelegate struct {
+	list.DefaultDelegate
+}
+
+func newEntryDelegate() entryDelegate {
+	return entryDelegate{list.NewDefaultDelegate()}
+}
+
+func (d entryDelegate) Render(w io.Writer, m list.Model, index int, listItem list.Item) {
+	i, ok := listItem.(item)
+	if !ok {
+		return
+	}
+	s := d.Styles
+	titleStyle, descStyle := s.NormalTitle, s.NormalDesc
+	if index == m.Index() {
+		titleStyle, descStyle = s.SelectedTitle, s.SelectedDesc
+	}
+
+	badge := levelBadgeStyle.Render("")
+	textStyle := lipgloss.NewStyle().Foreground(titleStyle.GetForeground())
+	if color, ok := levelColors[i.level]; ok {
+		badge = levelBadgeStyle.Copy().Foreground(color).Render(strings.ToUpper(i.level.String()))
+		textStyle = textStyle.Foreground(color)
+	}
+
+	title, desc := i.Title(), i.Description()
+	if i.highlight {
+		title = "» " + title
+		textStyle = textStyle.Copy().Bold(true).Underline(true)
+	}
+	if m.Width() > 0 {
+		textWidth := m.Width() - s.NormalTitle.GetPaddingLeft() - s.NormalTitle.GetPaddingRight()
+		titleWidth := textWidth - lipgloss.Width(badge) - 1
+		if titleWidth < 1 {
+			titleWidth = 1
+		}
+		title = truncate.StringWithTail(title, uint(titleWidth), "…")
+		if textWidth > 0 {
+			desc = truncate.StringWithTail(desc, uint(textWidth), "…")
+		}
+	}
+
+	// the title style only lays out the line, the badge and text bring their own colors
+	fmt.Fprintf(w, "%s\n%s", titleStyle.Copy().UnsetForeground().Render(badge+" "+textStyle.Render(title)), descStyle.Render(desc))
+}
@@ -1,13 +1,12 @@
 package main
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"errors"
 	"fmt"
-	"net/http"
 	"strings"
 
+	"github.com/TinyHatchet/client/tinyhatchet"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 )
@@ -45,14 +44,6 @@ const (
 
 type verificationRequired struct{}
 
-const (
-	MessageVerficationRequired = "verification required"
-)
-
-var (
-	errNoCredentials = errors.New("please enter credentials")
-)
-
 func LoginPage() tea.Model {
 	return loginPage{
 		view:        loginPageLogin,
@@ -67,7 +58,7 @@ func (l loginPage) Update(msg tea.Msg) (model tea.Model, cmd tea.Cmd) {
 	if l.view == loginPageLogin {
 		switch msg.(type) {
 		case verificationRequired:
-			appConfig.EmailAddress = l.loginForm.emailInput.Value()
+			appConfig.Profile().EmailAddress = l.loginForm.emailInput.Value()
 			l.view = loginPageConfirm
 			model, cmd = l.confirmForm.Update(msg)
 		default:
@@ -104,13 +95,14 @@ func LoginForm() loginForm {
 	t.CursorStyle = cursorStyle
 	t.Placeholder = "tinyhatchet@example.com"
 	t.Prompt = "Email    > "
-	if appConfig.EmailAddress == "" {
+	email := appConfig.Profile().EmailAddress
+	if email == "" {
 		s.focusIndex = loginFormIndexEmailInput
 		t.Focus()
 		t.PromptStyle = focusedStyle
 		t.TextStyle = focusedStyle
 	} else {
-		t.SetValue(appConfig.EmailAddress)
+		t.SetValue(email)
 	}
 
 	s.emailInput = t
@@ -121,7 +113,7 @@ func LoginForm() loginForm {
 	t.Prompt = "Password > "
 	t.EchoMode = textinput.EchoPassword
 	t.EchoCharacter = '*'
-	if appConfig.ServerURL != "" && appConfig.EmailAddress != "" {
+	if email != "" {
 		s.focusIndex = loginFormIndexPasswordInput
 		t.Focus()
 		t.PromptStyle = focusedStyle
@@ -146,12 +138,12 @@ func (m loginForm) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			s := msg.String()
 			if s == "enter" {
 				if m.focusIndex == loginFormIndexLoginButton {
-					m.emailErrors, m.passwordErrors = nil, nil
+					m.emailErrors, m.passwordErrors, m.Error = nil, nil, nil
 					return m, m.login
 				}
 
 				if m.focusIndex == loginFormIndexRegisterButton {
-					m.emailErrors, m.passwordErrors = nil, nil
+					m.emailErrors, m.passwordErrors, m.Error = nil, nil, nil
 					return m, m.register
 				}
 			}
@@ -190,8 +182,11 @@ func (m loginForm) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 	case success:
 		return initialModel(true), nil
-	case Errors:
-		m.emailErrors, m.passwordErrors = msg["email"], msg["password"]
+	case *tinyhatchet.APIError:
+		m.emailErrors, m.passwordErrors = msg.FieldErrors("email"), msg.FieldErrors("password")
+		if m.emailErrors == nil && m.passwordErrors == nil {
+			m.Error = msg
+		}
 		return m, nil
 	case error:
 		m.Error = msg
@@ -255,86 +250,27 @@ func (s loginForm) View() string {
 }
 
 func (m loginForm) login() tea.Msg {
-	loginCmd := map[string]string{}
-
-	if m.emailInput.Value() == "" || m.passwordInput.Value() == "" {
-		return errNoCredentials
-	}
-
-	loginCmd["email"], loginCmd["password"] = m.emailInput.Value(), m.passwordInput.Value()
-	b := bytes.NewBuffer(nil)
-	encoder := json.NewEncoder(b)
-	err := encoder.Encode(loginCmd)
-	if err != nil {
-		return err
-	}
-
-	httpResponse, err := httpClient.Post(appConfig.BuildURL("/auth/login"), contentTypeJSON, b)
-	if err != nil {
-		return err
-	}
-	response, err := ParseAPIResponse(httpResponse)
-	if err != nil {
-		return err
-	}
-
-	if response.Message == MessageVerficationRequired || response.Error == MessageVerficationRequired {
-		return verificationRequired{}
-	}
-	if response.Status == StatusSuccess {
-		return success{}
-	}
-	if response.Errors != nil {
-		if response.Error != "" {
-			response.Errors["error"] = []string{response.Error.Error()}
-		}
-		return response.Errors
-	}
-	return response.Error
-
+	err := client.Login(context.Background(), m.emailInput.Value(), m.passwordInput.Value())
+	return authResult(err)
 }
 
 func (m loginForm) register() tea.Msg {
-	cmd := map[string]string{}
-
-	if m.emailInput.Value() == "" || m.passwordInput.Value() == "" {
-		return errNoCredentials
-	}
-
-	cmd["email"], cmd["password"], cmd["confirm_password"] = m.emailInput.Value(), m.passwordInput.Value(), m.passwordInput.Value()
-	b := bytes.NewBuffer(nil)
-	encoder := json.NewEncoder(b)
-	err := encoder.Encode(cmd)
-	if err != nil {
-		return err
-	}
-
-	httpResponse, err := httpClient.Post(appConfig.BuildURL("/auth/register"), contentTypeJSON, b)
-	if err != nil {
-		return err
-	}
-	response, err := ParseAPIResponse(httpResponse)
-	if err != nil {
-		return err
-	}
-
-	return m.handleResponse(response)
+	err := client.Register(context.Background(), m.emailInput.Value(), m.passwordInput.Value())
+	return authResult(err)
 }
 
-func (l loginForm) handleResponse(response *APIResponse) tea.Msg {
-	if response.Message == MessageVerficationRequired || response.Error == MessageVerficationRequired {
-		return verificationRequired{}
-	}
-	if response.Status == StatusSuccess {
+// authResult turns the error from an auth call into the message the login page handles
+func authResult(err error) tea.Msg {
+	var apiErr *tinyhatchet.APIError
+	switch {
+	case err == nil:
 		return success{}
+	case errors.Is(err, tinyhatchet.ErrVerificationRequired):
+		return verificationRequired{}
+	case errors.As(err, &apiErr):
+		return apiErr
 	}
-	if response.Errors != nil {
-		if response.Error != "" {
-			response.Errors["error"] = []string{response.Error.Error()}
-		}
-		return response.Errors
-	}
-	return response.Error
+	return err
 }
 
 type confirmForm struct {
@@ -367,6 +303,7 @@ func (c confirmForm) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "ctrl+c", "q":
 			return c, tea.Quit
 		case "enter":
+			c.Error = nil
 			return c, c.confirm
 		}
 	case success:
@@ -387,36 +324,12 @@ func (c confirmForm) View() string {
 
 	b.WriteString("You must confirm your account before you can continue.\nCheck your email for a confirmation code and enter it below.\n\n")
 	b.WriteString(c.confirmInput.View())
+	if c.Error != nil {
+		fmt.Fprintf(&b, "\n\n%s\n", errorStyle.Render(strings.Title(c.Error.Error())))
+	}
 	return b.String()
 }
 func (c confirmForm) confirm() tea.Msg {
-	req, err := http.NewRequest(http.MethodGet, appConfig.BuildURL("/auth/confirm?cnf="+c.confirmInput.Value()), nil)
-	if err != nil {
-		return err
-	}
-	req.Header.Set("Content-Type", contentTypeJSON)
-
-	httpResponse, err := httpClient.Do(req)
-	if err != nil {
-		return err
-	}
-	response, err := ParseAPIResponse(httpResponse)
-	if err != nil {
-		return err
-	}
-
-	return c.handleResponse(response)
-}
-
-func (confirmForm) handleResponse(response *APIResponse) tea.Msg {
-	if response.Status == StatusSuccess {
-		return success{}
-	}
-	if response.Errors != nil {
-		if response.Error != "" {
-			response.Errors["error"] = []string{response.Error.Error()}
-		}
-		return response.Errors
-	}
-	return response.Error
+	err := client.Confirm(context.Background(), c.confirmInput.Value())
+	return authResult(err)
 }
@@ -3,23 +3,16 @@ package main
 import (
 	"flag"
 	"fmt"
-	"io/ioutil"
 	"log"
-	"net/http"
-	"net/http/cookiejar"
 	"os"
-	"time"
+	"strings"
 
+	"github.com/TinyHatchet/client/tinyhatchet"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
-	"golang.org/x/net/publicsuffix"
-
-	"gopkg.in/yaml.v2"
 )
 
 const (
-	contentTypeJSON = "application/json"
-
 	loginButtonText    = "[ Login ]"
 	registerButtonText = "[ Register ]"
 	submitButtonText   = "[ Submit ]"
@@ -36,17 +29,11 @@ var (
 )
 
 var (
-	httpClient *http.Client
-	width      int
-	height     int
+	client *tinyhatchet.Client
+	width  int
+	height int
 )
 
-type LogEntry struct {
-	Timestamp time.Time `json:"timestamp"`
-	Text      string    `json:"text"`
-	Tags      []string  `json:"tags"`
-}
-
 func initialModel(loggedIn bool) tea.Model {
 	if !loggedIn {
 		return LoginPage()
@@ -57,13 +44,17 @@ func initialModel(loggedIn bool) tea.Model {
 type mainMenu struct {
 	choices []string
 	cursor  int
+	Error   error
 }
 
 func home() mainMenu {
 	return mainMenu{
 		choices: []string{
 			"Search log entries",
+			"Saved searches",
 			"Account Management",
+			"Switch Profile",
+			"Logout",
 		},
 	}
 }
@@ -91,9 +82,20 @@ func (m mainMenu) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			case 0:
 				return search(), nil
 			case 1:
+				return SavedSearchMenu(), nil
+			case 2:
 				return account(), nil
+			case 3:
+				return ProfileMenu(), nil
+			case 4:
+				return m, logout
 			}
 		}
+	case loggedOut:
+		return LoginPage(), nil
+	case error:
+		m.Error = msg
+		return m, nil
 	case tea.WindowSizeMsg:
 		width, height = msg.Width, msg.Height
 	}
@@ -101,7 +103,8 @@ func (m mainMenu) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (m mainMenu) View() string {
-	s := "What do you want to do?\n\n"
+	s := fmt.Sprintf("Profile: %s %s\n\n", appConfig.CurrentProfile, blurredStyle.Render(client.ServerURL))
+	s += "What do you want to do?\n\n"
 
 	for i, choice := range m.choices {
 		cursor := " "
@@ -112,71 +115,84 @@ func (m mainMenu) View() string {
 		s += fmt.Sprintf("%s %s\n", cursor, choice)
 	}
 
-	s += "\nPress q to quit.\n"
-
-	return s
-}
-
-var appConfig Config
-
-type Config struct {
-	ServerURL    string
-	EmailAddress string
-	DebugPath    string
-}
-
-func (c *Config) LoadFromFile(path string) error {
-	body, err := ioutil.ReadFile(path)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return nil
-		}
-		return fmt.Errorf("read config file: %w", err)
+	if m.Error != nil {
+		s += "\n" + errorStyle.Render(strings.Title(m.Error.Error())) + "\n"
 	}
-	err = yaml.Unmarshal(body, c)
-	if err != nil {
-		return fmt.Errorf("unmarshal config: %w", err)
-	}
-	c.ServerURL = "https://tinyhatchet.com"
-	return nil
-}
 
-func (c Config) WriteOut(path string) error {
-	body, err := yaml.Marshal(c)
-	if err != nil {
-		return fmt.Errorf("marshal config: %w", err)
-	}
-	err = ioutil.WriteFile(path, body, 0600)
-	if err != nil {
-		return fmt.Errorf("write config file %w", err)
-	}
-	return nil
-}
+	s += "\nPress q to quit.\n"
 
-func (c Config) BuildURL(path string) string {
-	return fmt.Sprintf("%s%s", c.ServerURL, path)
+	return s
 }
 
 func main() {
 	homedir, _ := os.UserHomeDir()
 	defaultConfig := homedir + string(os.PathSeparator) + ".tinyhatchet.config"
-	var configPath string
+	var profileName, timeZone string
+	var flags overrides
 	flag.StringVar(&configPath, "config", defaultConfig, "")
+	flag.StringVar(&profileName, "profile", "", "profile from the config file to use")
+	flag.StringVar(&flags.ServerURL, "server", "", "server url, overrides "+envServerURL+" and the profile (default "+tinyhatchet.DefaultServerURL+")")
+	flag.StringVar(&flags.Token.ID, "token-id", "", "API token id, overrides "+envTokenID+" and the profile")
+	flag.StringVar(&flags.Token.Secret, "token-secret", "", "API token secret, overrides "+envTokenSecret+" and the profile")
+	flag.StringVar(&timeZone, "timezone", "", "time zone times are shown and read in: local, UTC or an IANA name, overrides the config")
+	flag.BoolVar(&offlineMode, "offline", false, "answer searches from the local cache without contacting the server")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [search|send [flags]]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
 	flag.Parse()
 
 	err := appConfig.LoadFromFile(configPath)
 	if err != nil {
 		log.Fatal(err)
 	}
+	if timeZone == "" {
+		timeZone = appConfig.TimeZone
+	}
+	err = setZone(timeZone)
+	if err != nil {
+		log.Fatal(err)
+	}
+	savedProfile := appConfig.CurrentProfile
+	if profileName != "" {
+		if _, ok := appConfig.Profiles[profileName]; !ok && profileName != defaultProfileName {
+			names := appConfig.ProfileNames()
+			if len(names) == 0 {
+				log.Fatalf("no profile named %q, create it from the profile menu first", profileName)
+			}
+			log.Fatalf("no profile named %q, choose one of: %s", profileName, strings.Join(names, ", "))
+		}
+		appConfig.CurrentProfile = profileName
+	}
 
-	defer appConfig.WriteOut(configPath)
+	defer func() {
+		// -profile only applies to this run unless the user switched profiles in the menu
+		if profileName != "" && appConfig.CurrentProfile == profileName {
+			appConfig.CurrentProfile = savedProfile
+		}
+		err := appConfig.WriteOut(configPath)
+		if err != nil {
+			log.Println(err)
+		}
+	}()
 
-	cookieJar, err := cookiejar.New(&cookiejar.Options{PublicSuffixList: publicsuffix.List})
-	httpClient = &http.Client{Jar: cookieJar}
+	loggedIn, err := connect(envOverrides(flags))
 	if err != nil {
-		log.Fatal(err)
+		if client == nil {
+			log.Fatal(err)
+		}
+		log.Println(err)
 	}
-	var loggedIn bool
+	defer saveCurrentSession()
+
+	if flag.NArg() > 0 {
+		err = runCommand(flag.Arg(0), flag.Args()[1:])
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	defer func() {
 		r := recover()
 		if r != nil {
@@ -184,8 +200,8 @@ func main() {
 		}
 	}()
 
-	if appConfig.DebugPath != "" {
-		_, _ = tea.LogToFile("debug.log", "")
+	if debugPath := appConfig.Profile().DebugPath; debugPath != "" {
+		_, _ = tea.LogToFile(debugPath, "")
 	}
 
 	p := tea.NewProgram(initialModel(loggedIn), tea.WithAltScreen())
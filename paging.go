@@ -0,0 +1,368 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/TinyHatchet/client/tinyhatchet"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	pageSize = 500
+	// pagePrefetch is how close the cursor gets to the last loaded entry before the next page is requested
+	pagePrefetch = 10
+	// streamBatchSize is how many decoded entries are added to the list at a time while a page downloads
+	streamBatchSize = 50
+)
+
+// pager keeps track of the pages of the current search and of the page being downloaded.
+// Every download gets a new id so that messages from a cancelled or replaced one are dropped.
+type pager struct {
+	id      int
+	next    *tinyhatchet.Query
+	loading bool
+	// replace is set until the first batch of a new search has replaced the previous results
+	replace  bool
+	query    tinyhatchet.Query
+	stream   *tinyhatchet.EntryStream
+	cancel   context.CancelFunc
+	received int
+	// search is the first page of the current search and started when it was run,
+	// fetched holds the entries of the page being downloaded until they are cached
+	search  tinyhatchet.Query
+	started time.Time
+	fetched []tinyhatchet.LogEntry
+	// added counts the entries of the page being downloaded that were not loaded yet
+	added int
+	// cached is set when the results came from the cache, offline when the server could not be reached
+	cached  bool
+	offline bool
+}
+
+type streamOpened struct {
+	id     int
+	stream *tinyhatchet.EntryStream
+}
+
+type entriesBatch struct {
+	id      int
+	entries []tinyhatchet.LogEntry
+	done    bool
+}
+
+// cachedEntries are the results of a search answered from the cache
+type cachedEntries struct {
+	id      int
+	entries []tinyhatchet.LogEntry
+	offline bool
+}
+
+// pageError ends a page download, entries are those decoded before the error
+type pageError struct {
+	id      int
+	entries []tinyhatchet.LogEntry
+	err     error
+}
+
+var errDownloadCancelled = errors.New("download cancelled")
+
+// eachPage runs fn with every page fetch returns for the query, following Next.
+// It stops after the last page, and at a page that only repeats the one before it,
+// which is what a server that ignores limit or offset sends.
+func eachPage(query tinyhatchet.Query, fetch func(tinyhatchet.Query) (tinyhatchet.Page, error), fn func(tinyhatchet.Page) error) error {
+	var previous map[string]struct{}
+	for {
+		page, err := fetch(query)
+		if err != nil {
+			return fmt.Errorf("search: %w", err)
+		}
+		keys := make(map[string]struct{}, len(page.Entries))
+		added := 0
+		for _, entry := range page.Entries {
+			key := entryKey(entry)
+			keys[key] = struct{}{}
+			if _, ok := previous[key]; !ok {
+				added++
+			}
+		}
+		if previous != nil && added == 0 {
+			return nil
+		}
+		err = fn(page)
+		if err != nil {
+			return err
+		}
+		if page.Next == nil {
+			return nil
+		}
+		previous, query = keys, *page.Next
+	}
+}
+
+// startSearch requests the first page of the query, replacing the current results once it arrives.
+// A range the cache holds part of is answered from the cache instead, once the rest is fetched.
+func (s *searchMenu) startSearch(query tinyhatchet.Query) tea.Cmd {
+	s.pages.stop()
+	s.pages.next = nil
+	s.pages.replace = true
+	s.pages.search = query
+	s.pages.started = time.Now()
+	s.pages.cached, s.pages.offline = false, false
+	if offlineMode && resultCache == nil {
+		return func() tea.Msg { return errCacheDisabled }
+	}
+	if resultCache != nil && (offlineMode || resultCache.holdsAny(query, s.pages.started)) {
+		return s.searchCache(offlineMode)
+	}
+	query.Limit = pageSize
+	return s.fetchPage(query)
+}
+
+// searchCache answers the current search from the cache, fetching the ranges it is missing
+// unless offline is set. The results of an offline search only hold what was fetched before.
+func (s *searchMenu) searchCache(offline bool) tea.Cmd {
+	s.pages.stop()
+	ctx, cancel := context.WithCancel(context.Background())
+	s.pages.loading = true
+	s.pages.cancel = cancel
+	s.pages.received = 0
+	id, query, now, cache := s.pages.id, s.pages.search, s.pages.started, resultCache
+	search := func() tea.Msg {
+		var entries []tinyhatchet.LogEntry
+		var err error
+		if offline {
+			entries, err = cache.entries(query, now)
+		} else {
+			entries, offline, err = cache.search(ctx, query, now)
+		}
+		if err != nil {
+			return pageError{id: id, err: err}
+		}
+		return cachedEntries{id: id, entries: entries, offline: offline}
+	}
+	if s.showResult {
+		return tea.Batch(search, s.list.StartSpinner())
+	}
+	return search
+}
+
+// cachePage stores the page that was just downloaded, recording the range of the search
+// as held once its last page is in
+func (s *searchMenu) cachePage() tea.Cmd {
+	if resultCache == nil {
+		return nil
+	}
+	cache, query, entries, started := resultCache, s.pages.search, s.pages.fetched, s.pages.started
+	complete := s.pages.next == nil
+	s.pages.fetched = nil
+	return func() tea.Msg {
+		err := cache.store(query, entries, complete, started)
+		if err != nil {
+			log.Println(err)
+		}
+		return nil
+	}
+}
+
+// replaceEntries shows the first entries of a new search in place of the previous results
+func (s *searchMenu) replaceEntries(entries []tinyhatchet.LogEntry) tea.Cmd {
+	s.pages.replace = false
+	cmd := s.loadEntries(entries)
+	s.list.SetFilteringEnabled(false)
+	s.showResult = true
+	s.layout()
+	return cmd
+}
+
+func (s *searchMenu) fetchPage(query tinyhatchet.Query) tea.Cmd {
+	s.pages.stop()
+	ctx, cancel := context.WithCancel(context.Background())
+	s.pages.loading = true
+	s.pages.query = query
+	s.pages.cancel = cancel
+	s.pages.received = 0
+	s.pages.fetched = nil
+	s.pages.added = 0
+	id := s.pages.id
+	openStream := func() tea.Msg {
+		stream, err := client.StreamEntries(ctx, query)
+		if err != nil {
+			return pageError{id: id, err: err}
+		}
+		return streamOpened{id: id, stream: stream}
+	}
+	if s.showResult {
+		return tea.Batch(openStream, s.list.StartSpinner())
+	}
+	return openStream
+}
+
+// stop aborts the download in progress, if any
+func (p *pager) stop() {
+	p.id++
+	if p.cancel != nil {
+		p.cancel()
+		p.cancel = nil
+	}
+	if p.stream != nil {
+		p.stream.Close()
+		p.stream = nil
+	}
+	p.loading = false
+}
+
+func readBatch(id int, stream *tinyhatchet.EntryStream) tea.Cmd {
+	return func() tea.Msg {
+		entries, err := stream.Next(streamBatchSize)
+		if errors.Is(err, io.EOF) {
+			return entriesBatch{id: id, entries: entries, done: true}
+		}
+		if err != nil {
+			return pageError{id: id, entries: entries, err: err}
+		}
+		return entriesBatch{id: id, entries: entries}
+	}
+}
+
+// updatePaging handles the messages of a page download
+func (s *searchMenu) updatePaging(msg tea.Msg) tea.Cmd {
+	switch msg := msg.(type) {
+	case streamOpened:
+		if msg.id != s.pages.id {
+			msg.stream.Close()
+			return nil
+		}
+		s.pages.stream = msg.stream
+		return readBatch(msg.id, msg.stream)
+	case entriesBatch:
+		if msg.id != s.pages.id {
+			return nil
+		}
+		s.pages.received += len(msg.entries)
+		s.pages.fetched = append(s.pages.fetched, msg.entries...)
+		loaded := len(s.entries)
+		var cmd tea.Cmd
+		if s.pages.replace {
+			loaded = 0
+			cmd = s.replaceEntries(msg.entries)
+			if !msg.done {
+				cmd = tea.Batch(cmd, s.list.StartSpinner())
+			}
+		} else {
+			cmd = s.appendEntries(msg.entries, false)
+		}
+		s.pages.added += len(s.entries) - loaded
+		if !msg.done {
+			s.list.Title = s.resultTitle()
+			return tea.Batch(cmd, readBatch(msg.id, s.pages.stream))
+		}
+
+		// a page that adds nothing comes from a server ignoring limit or offset,
+		// asking for the next one would only bring the same entries again
+		query := s.pages.query
+		s.pages.next = nil
+		if query.Limit > 0 && s.pages.received == query.Limit && s.pages.added > 0 {
+			query.Offset += s.pages.received
+			s.pages.next = &query
+		}
+		s.pages.stop()
+		s.list.StopSpinner()
+		s.list.Title = s.resultTitle()
+		return tea.Batch(cmd, s.cachePage(), s.nextPage())
+	case cachedEntries:
+		if msg.id != s.pages.id {
+			return nil
+		}
+		s.pages.stop()
+		s.pages.cached, s.pages.offline = true, msg.offline
+		cmd := s.replaceEntries(msg.entries)
+		s.list.StopSpinner()
+		s.list.Title = s.resultTitle()
+		if msg.offline && !offlineMode {
+			cmd = tea.Batch(cmd, s.list.NewStatusMessage(errorStyle.Render("Server unreachable, showing cached entries")))
+		}
+		return cmd
+	case pageError:
+		if msg.id != s.pages.id {
+			return nil
+		}
+		if s.pages.replace && len(msg.entries) == 0 && resultCache != nil && tinyhatchet.IsUnreachable(msg.err) {
+			return s.searchCache(true)
+		}
+		var cmd tea.Cmd
+		if len(msg.entries) > 0 {
+			s.pages.received += len(msg.entries)
+			if s.pages.replace {
+				cmd = s.replaceEntries(msg.entries)
+			} else {
+				cmd = s.appendEntries(msg.entries, false)
+			}
+		}
+		s.pages.stop()
+		s.list.StopSpinner()
+		s.list.Title = s.resultTitle()
+		err := msg.err
+		return tea.Batch(cmd, func() tea.Msg { return err })
+	}
+	return nil
+}
+
+// cancelDownload stops the page being downloaded, keeping the entries received so far
+func (s *searchMenu) cancelDownload() tea.Cmd {
+	if !s.pages.loading {
+		return nil
+	}
+	received := s.pages.received
+	s.pages.stop()
+	s.pages.next = nil
+	s.list.StopSpinner()
+	s.list.Title = s.resultTitle()
+	if !s.showResult {
+		s.Error = errDownloadCancelled
+		return nil
+	}
+	return s.list.NewStatusMessage(fmt.Sprintf("Download cancelled after %d entries", received))
+}
+
+// nextPage requests the following page when the cursor is close to the end of the loaded entries
+func (s *searchMenu) nextPage() tea.Cmd {
+	if s.pages.next == nil || s.pages.loading {
+		return nil
+	}
+	if s.list.Index() < len(s.list.Items())-pagePrefetch {
+		return nil
+	}
+	cmd := s.fetchPage(*s.pages.next)
+	s.list.Title = s.resultTitle()
+	return cmd
+}
+
+// resultTitle describes how many entries are loaded and what the list is doing
+func (s searchMenu) resultTitle() string {
+	title := fmt.Sprintf("%s %d loaded", resultTitle, len(s.entries))
+	if s.filter.filter != nil || s.facets.active() || s.minLevel != levelUnknown {
+		title += fmt.Sprintf(", %d shown", len(s.list.Items()))
+	}
+	if s.pages.offline {
+		title += ", offline"
+	} else if s.pages.cached {
+		title += ", from cache"
+	}
+	if s.sort != sortOldest {
+		title += ", " + s.sort.String()
+	}
+	if s.pages.loading {
+		title += fmt.Sprintf(", receiving (%d)…", s.pages.received)
+	} else if s.pages.next != nil {
+		title += ", scroll for more"
+	}
+	if s.follow.following {
+		title += " (following)"
+	}
+	return title
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/TinyHatchet/client/tinyhatchet"
+)
+
+func TestEachPageEnds(t *testing.T) {
+	tests := []struct {
+		name string
+		// serve returns the first entry and the number of entries the server
+		// sends for a request with offset and limit
+		serve   func(offset, limit int) (int, int)
+		entries int
+	}{
+		{"last page short", func(offset, limit int) (int, int) { return offset, minInt(limit, cliPageSize+10-offset) }, cliPageSize + 10},
+		{"exact multiple", func(offset, limit int) (int, int) { return offset, minInt(limit, 2*cliPageSize-offset) }, 2 * cliPageSize},
+		{"ignores offset", func(offset, limit int) (int, int) { return 0, limit }, cliPageSize},
+		{"ignores limit and offset", func(offset, limit int) (int, int) { return 0, cliPageSize }, cliPageSize},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			requests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				var offset, limit int
+				fmt.Sscan(r.URL.Query().Get("offset"), &offset)
+				fmt.Sscan(r.URL.Query().Get("limit"), &limit)
+				first, n := test.serve(offset, limit)
+				entries := []tinyhatchet.LogEntry{}
+				for i := first; i < first+n; i++ {
+					entries = append(entries, tinyhatchet.LogEntry{
+						Timestamp: time.Unix(int64(i), 0).UTC(),
+						Text:      fmt.Sprintf("entry %d", i),
+					})
+				}
+				json.NewEncoder(w).Encode(entries)
+			}))
+			defer server.Close()
+
+			var err error
+			client, err = tinyhatchet.NewClient(server.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			received := 0
+			fetch := func(query tinyhatchet.Query) (tinyhatchet.Page, error) {
+				return client.SearchPage(context.Background(), query)
+			}
+			err = eachPage(tinyhatchet.Query{Limit: cliPageSize}, fetch, func(page tinyhatchet.Page) error {
+				received += len(page.Entries)
+				if requests > 10 {
+					t.Fatal("paging did not stop")
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if received != test.entries {
+				t.Errorf("received %d entries, want %d", received, test.entries)
+			}
+		})
+	}
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
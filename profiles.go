@@ -0,0 +1,315 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const createNewProfileText = "Create New Profile"
+
+var (
+	errNoProfileName     = errors.New("please enter a profile name")
+	errProfileNameExists = errors.New("a profile with that name already exists")
+)
+
+// profileSwitched carries the connection made for the named profile
+type profileSwitched struct {
+	name string
+	conn connection
+}
+
+type profileMenu struct {
+	cursor int
+	names  []string
+	Error  error
+}
+
+func ProfileMenu() profileMenu {
+	m := profileMenu{names: appConfig.ProfileNames()}
+	for i, name := range m.names {
+		if name == appConfig.CurrentProfile {
+			m.cursor = i
+		}
+	}
+	return m
+}
+
+func (m profileMenu) Init() tea.Cmd {
+	return nil
+}
+
+func (m profileMenu) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc":
+			return home(), nil
+		case "ctrl+c", "q":
+			return m, tea.Quit
+		case "up", "k":
+			if m.cursor > 0 {
+				m.cursor--
+			}
+		case "down", "j":
+			if m.cursor < len(m.names) {
+				m.cursor++
+			}
+		case "enter", " ":
+			if m.cursor == len(m.names) {
+				return newProfile(), nil
+			}
+			m.Error = nil
+			return m, switchProfile(m.names[m.cursor])
+		}
+	case profileSwitched:
+		return msg.apply(), nil
+	case error:
+		m.Error = msg
+		return m, nil
+	case tea.WindowSizeMsg:
+		width, height = msg.Width, msg.Height
+	}
+	return m, nil
+}
+
+func (m profileMenu) View() string {
+	b := &strings.Builder{}
+
+	b.WriteString(titleStyle.Render("Profiles"))
+	b.WriteString("\n\n")
+
+	for i, name := range m.names {
+		cursor := " "
+		if m.cursor == i {
+			cursor = ">"
+		}
+		current := " "
+		if name == appConfig.CurrentProfile {
+			current = "*"
+		}
+		profile := appConfig.Profiles[name]
+		fmt.Fprintf(b, "%s %s %s", cursor, current, name)
+		if profile.ServerURL != "" {
+			fmt.Fprintf(b, " %s", blurredStyle.Render(profile.ServerURL))
+		}
+		if profile.EmailAddress != "" {
+			fmt.Fprintf(b, " %s", blurredStyle.Render(profile.EmailAddress))
+		}
+		b.WriteRune('\n')
+	}
+	cursor := " "
+	if m.cursor == len(m.names) {
+		cursor = ">"
+	}
+	fmt.Fprintf(b, "%s   %s\n", cursor, createNewProfileText)
+
+	if m.Error != nil {
+		fmt.Fprintf(b, "\n%s\n", errorStyle.Render(strings.Title(m.Error.Error())))
+	}
+
+	fmt.Fprint(b, "\nPress q to quit.\n")
+
+	return b.String()
+}
+
+// switchProfile connects with the named profile in the background,
+// the switch itself happens in Update once profileSwitched arrives
+func switchProfile(name string) tea.Cmd {
+	profile, ok := appConfig.Profiles[name]
+	if !ok {
+		err := fmt.Errorf("no profile named %q", name)
+		return func() tea.Msg { return err }
+	}
+	_, err := resolveServerURL("", *profile)
+	if err != nil {
+		return func() tea.Msg { return err }
+	}
+
+	p, cache := *profile, !appConfig.DisableCache
+	return func() tea.Msg {
+		conn, err := dial(name, p, cache, overrides{})
+		if conn.client == nil {
+			return err
+		}
+		if err != nil {
+			log.Println(err)
+		}
+		return profileSwitched{name: name, conn: conn}
+	}
+}
+
+// apply stores the session of the current profile and makes the new one current
+func (msg profileSwitched) apply() tea.Model {
+	saveCurrentSession()
+	appConfig.CurrentProfile = msg.name
+	msg.conn.use()
+	return initialModel(msg.conn.loggedIn)
+}
+
+type newProfileForm struct {
+	focusIndex int
+	inputs     []titledInput
+	Error      error
+}
+
+const (
+	newProfileName = iota
+	newProfileServerURL
+	newProfileEmail
+)
+
+func newProfile() newProfileForm {
+	s := newProfileForm{inputs: make([]titledInput, 3)}
+
+	for i := range s.inputs {
+		t := titledInput{}
+		t.Model = textinput.NewModel()
+		t.CursorStyle = cursorStyle
+		t.CharLimit = 0
+		t.SetCursorMode(textinput.CursorStatic)
+
+		switch i {
+		case newProfileName:
+			t.Title = "Name      "
+			t.Placeholder = "staging"
+			t.Focus()
+			t.PromptStyle = focusedStyle
+			t.TextStyle = focusedStyle
+		case newProfileServerURL:
+			t.Title = "Server URL"
+			t.Placeholder = "https://tinyhatchet.com"
+		case newProfileEmail:
+			t.Title = "Email     "
+			t.Placeholder = "tinyhatchet@example.com"
+		}
+		s.inputs[i] = t
+	}
+
+	return s
+}
+
+func (form newProfileForm) Init() tea.Cmd {
+	return nil
+}
+
+func (form newProfileForm) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c":
+			return form, tea.Quit
+		case "esc":
+			return ProfileMenu(), nil
+		case "tab", "shift+tab", "enter", "up", "down":
+			s := msg.String()
+			if s == "enter" && form.focusIndex == len(form.inputs) {
+				name, err := form.create()
+				form.Error = err
+				if err != nil {
+					return form, nil
+				}
+				return form, switchProfile(name)
+			}
+			if s == "up" || s == "shift+tab" {
+				form.focusIndex--
+			} else {
+				form.focusIndex++
+			}
+
+			if form.focusIndex > len(form.inputs) {
+				form.focusIndex = 0
+			} else if form.focusIndex < 0 {
+				form.focusIndex = len(form.inputs)
+			}
+
+			cmds := make([]tea.Cmd, len(form.inputs))
+			for i := 0; i <= len(form.inputs)-1; i++ {
+				if i == form.focusIndex {
+					cmds[i] = form.inputs[i].Focus()
+					form.inputs[i].PromptStyle = focusedStyle
+					form.inputs[i].TextStyle = focusedStyle
+					continue
+				}
+				form.inputs[i].Blur()
+				form.inputs[i].PromptStyle = noStyle
+				form.inputs[i].TextStyle = noStyle
+			}
+			return form, tea.Batch(cmds...)
+		}
+	case profileSwitched:
+		return msg.apply(), nil
+	case error:
+		form.Error = msg
+		return form, nil
+	case tea.WindowSizeMsg:
+		width, height = msg.Width, msg.Height
+	}
+	return form, form.updateInputs(msg)
+}
+
+func (form *newProfileForm) updateInputs(msg tea.Msg) tea.Cmd {
+	var cmds = make([]tea.Cmd, len(form.inputs))
+
+	for i := range form.inputs {
+		form.inputs[i].Model, cmds[i] = form.inputs[i].Update(msg)
+	}
+
+	return tea.Batch(cmds...)
+}
+
+func (form newProfileForm) View() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("New Profile"))
+	b.WriteString("\n\n")
+	for i := range form.inputs {
+		b.WriteString(form.inputs[i].Title)
+		b.WriteRune(' ')
+		b.WriteString(form.inputs[i].View())
+		if i < len(form.inputs)-1 {
+			b.WriteRune('\n')
+		}
+	}
+	if form.Error != nil {
+		fmt.Fprintf(&b, "\n\n%s", errorStyle.Render(strings.Title(form.Error.Error())))
+	}
+
+	buttonStyle := &blurredStyle
+	if form.focusIndex == len(form.inputs) {
+		buttonStyle = &focusedStyle
+	}
+	fmt.Fprintf(&b, "\n\n%s\n\n", buttonStyle.Render(submitButtonText))
+
+	return b.String()
+}
+
+// create validates the form and adds the profile, returning its name
+func (form newProfileForm) create() (string, error) {
+	name := strings.TrimSpace(form.inputs[newProfileName].Value())
+	if name == "" {
+		return "", errNoProfileName
+	}
+	err := validateProfileName(name)
+	if err != nil {
+		return "", err
+	}
+	if _, ok := appConfig.Profiles[name]; ok {
+		return "", errProfileNameExists
+	}
+	profile := Profile{
+		ServerURL:    strings.TrimSpace(form.inputs[newProfileServerURL].Value()),
+		EmailAddress: strings.TrimSpace(form.inputs[newProfileEmail].Value()),
+	}
+	_, err = resolveServerURL("", profile)
+	if err != nil {
+		return "", err
+	}
+
+	*appConfig.AddProfile(name) = profile
+	return name, nil
+}
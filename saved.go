@@ -0,0 +1,214 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+var errNoSearchName = errors.New("please enter a name for the search")
+
+var saveSearchKey = key.NewBinding(key.WithKeys("ctrl+s"), key.WithHelp("ctrl+s", "save search"))
+
+type savedSearchMenu struct {
+	cursor int
+	names  []string
+	Error  error
+}
+
+func SavedSearchMenu() savedSearchMenu {
+	return savedSearchMenu{names: appConfig.Profile().SavedSearchNames()}
+}
+
+func (m savedSearchMenu) Init() tea.Cmd {
+	return nil
+}
+
+func (m savedSearchMenu) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc":
+			return home(), nil
+		case "ctrl+c", "q":
+			return m, tea.Quit
+		case "up", "k":
+			if m.cursor > 0 {
+				m.cursor--
+			}
+		case "down", "j":
+			if m.cursor < len(m.names)-1 {
+				m.cursor++
+			}
+		case "enter", " ":
+			if len(m.names) == 0 {
+				return m, nil
+			}
+			return runSavedSearch(appConfig.Profile().SavedSearches[m.names[m.cursor]])
+		case "d", "delete":
+			if len(m.names) == 0 {
+				return m, nil
+			}
+			delete(appConfig.Profile().SavedSearches, m.names[m.cursor])
+			m.names = appConfig.Profile().SavedSearchNames()
+			if m.cursor >= len(m.names) && m.cursor > 0 {
+				m.cursor--
+			}
+		}
+	case error:
+		m.Error = msg
+		return m, nil
+	case tea.WindowSizeMsg:
+		width, height = msg.Width, msg.Height
+	}
+	return m, nil
+}
+
+func (m savedSearchMenu) View() string {
+	b := &strings.Builder{}
+
+	b.WriteString(titleStyle.Render("Saved Searches"))
+	b.WriteString("\n\n")
+
+	if len(m.names) == 0 {
+		fmt.Fprintf(b, "%s\n", blurredStyle.Render("No saved searches, press ctrl+s on the search screen to save one."))
+	}
+	searches := appConfig.Profile().SavedSearches
+	for i, name := range m.names {
+		cursor := " "
+		if m.cursor == i {
+			cursor = ">"
+		}
+		fmt.Fprintf(b, "%s %s %s\n", cursor, name, blurredStyle.Render(searches[name].String()))
+	}
+
+	if m.Error != nil {
+		fmt.Fprintf(b, "\n%s\n", errorStyle.Render(strings.Title(m.Error.Error())))
+	}
+
+	fmt.Fprint(b, "\nPress enter to run, d to delete, esc to go back or q to quit.\n")
+
+	return b.String()
+}
+
+// String summarises the inputs of the search
+func (s SavedSearch) String() string {
+	parts := []string{}
+	if s.Start != "" || s.End != "" {
+		start, end := s.Start, s.End
+		if start == "" {
+			start = "…"
+		}
+		if end == "" {
+			end = "now"
+		}
+		parts = append(parts, start+" → "+end)
+	}
+	if s.Tags != "" {
+		parts = append(parts, "tags: "+s.Tags)
+	}
+	if s.Text != "" {
+		parts = append(parts, fmt.Sprintf("text: %q", s.Text))
+	}
+	if s.Level != "" {
+		parts = append(parts, "level ≥ "+s.Level)
+	}
+	if len(parts) == 0 {
+		return "everything"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// runSavedSearch opens the search screen with the saved inputs and submits it
+func runSavedSearch(saved SavedSearch) (tea.Model, tea.Cmd) {
+	s := search()
+	s.setInputs(saved)
+	return s, s.submit()
+}
+
+// savePrompt asks for the name the search inputs are saved under
+type savePrompt struct {
+	active bool
+	input  textinput.Model
+	// status reports the outcome of the last save under the search form
+	status string
+}
+
+func newSavePrompt() savePrompt {
+	p := savePrompt{active: true}
+	p.input = textinput.NewModel()
+	p.input.CursorStyle = cursorStyle
+	p.input.Prompt = "Save search as > "
+	p.input.PromptStyle = focusedStyle
+	p.input.TextStyle = focusedStyle
+	p.input.Focus()
+	return p
+}
+
+func (p savePrompt) View() string {
+	if p.status != "" {
+		return p.input.View() + " " + p.status
+	}
+	return p.input.View() + " " + blurredStyle.Render("(enter to save, esc to cancel)")
+}
+
+// updateSave handles input while the save prompt is open
+func (s *searchMenu) updateSave(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "ctrl+c":
+		return tea.Quit
+	case "esc":
+		s.save.active = false
+		s.save.status = ""
+		s.layout()
+		return nil
+	case "enter":
+		name := strings.TrimSpace(s.save.input.Value())
+		if name == "" {
+			s.save.status = errorStyle.Render(strings.Title(errNoSearchName.Error()))
+			return nil
+		}
+		appConfig.Profile().SaveSearch(name, s.savedSearch())
+		s.save.active = false
+		s.layout()
+		status := fmt.Sprintf("Saved search %q", name)
+		if s.showResult {
+			s.save.status = ""
+			return s.list.NewStatusMessage(status)
+		}
+		s.save.status = focusedStyle.Render(status)
+		return nil
+	}
+	var cmd tea.Cmd
+	s.save.input, cmd = s.save.input.Update(msg)
+	return cmd
+}
+
+func (s searchMenu) savedSearch() SavedSearch {
+	return SavedSearch{
+		Start: s.inputs[searchStart].Value(),
+		End:   s.inputs[searchEnd].Value(),
+		Tags:  s.inputs[searchTags].Value(),
+		Text:  s.inputs[searchText].Value(),
+		Level: s.inputs[searchLevel].Value(),
+	}
+}
+
+func (s *searchMenu) setInputs(saved SavedSearch) {
+	s.inputs[searchStart].SetValue(saved.Start)
+	s.inputs[searchEnd].SetValue(saved.End)
+	s.inputs[searchTags].SetValue(saved.Tags)
+	s.inputs[searchText].SetValue(saved.Text)
+	s.inputs[searchLevel].SetValue(saved.Level)
+	for i := range s.inputs {
+		s.inputs[i].CursorEnd()
+		if i != s.focusIndex {
+			// moving the cursor shows it, blurring hides it again
+			s.inputs[i].Blur()
+		}
+	}
+}
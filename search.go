@@ -1,65 +1,151 @@
 package main
 
 import (
-	"encoding/json"
 	"fmt"
-	"io"
-	"log"
-	"net/url"
 	"strings"
 	"time"
 
+	"github.com/TinyHatchet/client/tinyhatchet"
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
 )
 
 const (
 	vertMargin  = 1
 	horizMargin = 2
+
+	resultTitle = "Found Log Entries:"
+
+	statusMessageLifetime = 5 * time.Second
+)
+
+const (
+	searchStart = iota
+	searchEnd
+	searchTags
+	searchText
+	searchLevel
 )
 
 type item struct {
-	LogEntry
+	tinyhatchet.LogEntry
+	level level
+	// highlight marks the entry the surrounding view was opened from
+	highlight bool
+}
+
+func newItem(entry tinyhatchet.LogEntry) item {
+	return item{LogEntry: entry, level: entryLevel(entry)}
 }
 
-func (i item) Title() string       { return fmt.Sprintf("%s: %s", i.Timestamp.Format(time.RFC3339), i.Text) }
+func (i item) Title() string       { return fmt.Sprintf("%s: %s", displayTime(i.Timestamp), i.Text) }
 func (i item) Description() string { return strings.Join(i.Tags, ",") }
 func (i item) FilterValue() string { return i.Text }
 
 type searchMenu struct {
-	showResult bool
-	focusIndex int
-	inputs     []textinput.Model
-	list       list.Model
+	showResult  bool
+	focusIndex  int
+	inputs      []textinput.Model
+	inputErrors []error
+	list        list.Model
+	lastQuery   tinyhatchet.Query
+	follow      follower
+	pages       pager
+	export      exportPrompt
+	detail      entryDetail
+	filter      filterPrompt
+	save        savePrompt
+	history     searchHistory
+	timeline    histogram
+	facets      facetPanel
+	surrounding surroundingView
+	zoomed      []SavedSearch
+	minLevel    level
+	sort        sortMode
+	entries     []tinyhatchet.LogEntry
+	Error       error
+}
+
+type resultKeyMap struct {
+	Open       key.Binding
+	Context    key.Binding
+	Follow     key.Binding
+	Export     key.Binding
+	Filter     key.Binding
+	Tags       key.Binding
+	FocusTags  key.Binding
+	PrevBucket key.Binding
+	NextBucket key.Binding
+	Zoom       key.Binding
+	ZoomOut    key.Binding
+	TimeZone   key.Binding
+	Sort       key.Binding
+	SortBack   key.Binding
+	Cancel     key.Binding
+}
+
+var resultKeys = resultKeyMap{
+	Open:       key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "details")),
+	Context:    key.NewBinding(key.WithKeys("c"), key.WithHelp("c", "surrounding logs")),
+	Follow:     key.NewBinding(key.WithKeys("F"), key.WithHelp("F", "follow")),
+	Export:     key.NewBinding(key.WithKeys("e"), key.WithHelp("e", "export")),
+	Filter:     key.NewBinding(key.WithKeys("/"), key.WithHelp("/", "filter")),
+	Tags:       key.NewBinding(key.WithKeys("t"), key.WithHelp("t", "tag panel")),
+	FocusTags:  key.NewBinding(key.WithKeys("tab"), key.WithHelp("tab", "select tags")),
+	PrevBucket: key.NewBinding(key.WithKeys("["), key.WithHelp("[", "previous bucket")),
+	NextBucket: key.NewBinding(key.WithKeys("]"), key.WithHelp("]", "next bucket")),
+	Zoom:       key.NewBinding(key.WithKeys("z"), key.WithHelp("z", "zoom into bucket")),
+	ZoomOut:    key.NewBinding(key.WithKeys("Z"), key.WithHelp("Z", "zoom out")),
+	Sort:       key.NewBinding(key.WithKeys("o"), key.WithHelp("o", "sort order")),
+	SortBack:   key.NewBinding(key.WithKeys("O"), key.WithHelp("O", "previous sort order")),
+	TimeZone:   key.NewBinding(key.WithKeys("T"), key.WithHelp("T", "switch time zone")),
+	Cancel:     key.NewBinding(key.WithKeys("ctrl+x"), key.WithHelp("ctrl+x", "cancel download")),
+}
+
+func (k resultKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Open, k.Context, k.Follow, k.Export, k.Filter, k.Sort, saveSearchKey, k.Cancel}
+}
+
+func (k resultKeyMap) FullHelp() []key.Binding {
+	return []key.Binding{k.Open, k.Context, k.Follow, k.Export, k.Filter, k.Sort, k.SortBack, k.Tags, k.FocusTags, saveSearchKey, k.PrevBucket, k.NextBucket, k.Zoom, k.ZoomOut, k.TimeZone, k.Cancel}
 }
 
 func search() searchMenu {
 	s := searchMenu{
-		inputs: make([]textinput.Model, 3),
-		list:   list.NewModel(nil, list.NewDefaultDelegate(), width-(2*horizMargin), height-(2*vertMargin)),
+		inputs:      make([]textinput.Model, 5),
+		inputErrors: make([]error, 5),
+		history:     newSearchHistory(loadHistory(historyPath)),
+		list:        list.NewModel(nil, newEntryDelegate(), width-(2*horizMargin), height-(2*vertMargin)),
 	}
 
-	s.list.Title = "Found Log Entries:"
+	s.list.Title = s.resultTitle()
+	s.list.AdditionalShortHelpKeys = resultKeys.ShortHelp
+	s.list.AdditionalFullHelpKeys = resultKeys.FullHelp
+	s.list.StatusMessageLifetime = statusMessageLifetime
 
 	var t textinput.Model
 	for i := range s.inputs {
 		t = textinput.NewModel()
 		t.CursorStyle = cursorStyle
-		t.CharLimit = 20
 		t.SetCursorMode(textinput.CursorStatic)
 
 		switch i {
-		case 0:
-			t.Placeholder = "Start (2021-06-01T11:22:33Z)"
+		case searchStart:
+			t.Placeholder = "Start (-15m, 1h ago, yesterday 09:00, 2021-06-01 11:22)"
 			t.Focus()
 			t.PromptStyle = focusedStyle
 			t.TextStyle = focusedStyle
-		case 1:
-			t.Placeholder = "Start (2021-06-01T11:22:33Z)"
-		case 2:
+		case searchEnd:
+			t.Placeholder = "End (now, today 18:00, 1622546553, 2021-06-01T11:22:33+02:00)"
+		case searchTags:
 			t.Placeholder = "Tags (comma separated)"
-			t.CharLimit = 0
+		case searchText:
+			t.Placeholder = "Text (full-text query)"
+		case searchLevel:
+			t.Placeholder = "Minimum level (debug, info, warn, error)"
 		}
 
 		s.inputs[i] = t
@@ -74,14 +160,108 @@ func (s searchMenu) Init() tea.Cmd {
 func (m searchMenu) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		if m.pages.loading && key.Matches(msg, resultKeys.Cancel) {
+			return m, m.cancelDownload()
+		}
+		if m.save.active {
+			return m, m.updateSave(msg)
+		}
+		if !m.showResult && m.history.finder.active {
+			return m, m.updateFinder(msg)
+		}
+		if !m.showResult && m.recallHistory(msg) {
+			return m, nil
+		}
+		if m.showResult && m.export.active {
+			return m, m.updateExport(msg)
+		}
+		if m.showResult && m.filter.active {
+			return m, m.updateFilter(msg)
+		}
+		if m.showResult && m.detail.active {
+			return m, m.updateDetail(msg)
+		}
+		if m.showResult && m.surrounding.active {
+			return m, m.updateSurrounding(msg)
+		}
+		if m.showResult && m.facets.focused {
+			return m, m.updateFacets(msg)
+		}
+		if key.Matches(msg, saveSearchKey) {
+			m.save = newSavePrompt()
+			m.layout()
+			return m, nil
+		}
+		if m.showResult && key.Matches(msg, resultKeys.Context) {
+			return m, m.showSurrounding()
+		}
+		if m.showResult && key.Matches(msg, resultKeys.Open) {
+			selected, ok := m.list.SelectedItem().(item)
+			if !ok {
+				return m, nil
+			}
+			top, right, bottom, left := docStyle.GetMargin()
+			m.detail = newEntryDetail(selected.LogEntry, width-left-right, height-top-bottom)
+			return m, nil
+		}
+		if m.showResult && key.Matches(msg, resultKeys.Follow) {
+			return m, m.toggleFollow()
+		}
+		if m.showResult && key.Matches(msg, resultKeys.Export) {
+			m.export = newExportPrompt()
+			m.layout()
+			return m, nil
+		}
+		if m.showResult && key.Matches(msg, resultKeys.Tags) {
+			m.facets.visible = !m.facets.visible
+			m.facets.focused = m.facets.visible
+			m.layout()
+			return m, nil
+		}
+		if m.showResult && m.facets.visible && key.Matches(msg, resultKeys.FocusTags) {
+			m.facets.focused = true
+			return m, nil
+		}
+		if m.showResult && key.Matches(msg, resultKeys.PrevBucket) {
+			m.moveBucket(-1)
+			return m, nil
+		}
+		if m.showResult && key.Matches(msg, resultKeys.NextBucket) {
+			m.moveBucket(1)
+			return m, nil
+		}
+		if m.showResult && key.Matches(msg, resultKeys.Zoom) {
+			return m, m.zoom()
+		}
+		if m.showResult && key.Matches(msg, resultKeys.ZoomOut) {
+			return m, m.zoomOut()
+		}
+		if m.showResult && key.Matches(msg, resultKeys.Sort) {
+			return m, m.sortBy(m.sort.next(1))
+		}
+		if m.showResult && key.Matches(msg, resultKeys.SortBack) {
+			return m, m.sortBy(m.sort.next(-1))
+		}
+		if m.showResult && key.Matches(msg, resultKeys.TimeZone) {
+			toggleZone()
+			return m, tea.Batch(m.showEntries(), m.list.NewStatusMessage("Times in "+zoneName(displayZone)))
+		}
+		if m.showResult && key.Matches(msg, resultKeys.Filter) {
+			m.filter.open()
+			m.layout()
+			return m, nil
+		}
 		switch msg.String() {
-		case "ctrl+c", "q":
+		case "ctrl+c":
 			return m, tea.Quit
 		case "esc":
+			m.pages.stop()
 			if !m.showResult {
 				return home(), nil
 			}
 			m.showResult = false
+			m.list.StopSpinner()
+			m.stopFollowing()
 			return m, nil
 		case "tab", "shift+tab", "enter", "up", "down":
 			if m.showResult {
@@ -89,7 +269,11 @@ func (m searchMenu) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			s := msg.String()
 			if s == "enter" && m.focusIndex == len(m.inputs) {
-				return m, m.getEntries
+				m.Error = nil
+				m.save.status = ""
+				m.zoomed = nil
+				m.timeline.cursor = 0
+				return m, m.submit()
 			}
 			if s == "up" || s == "shift+tab" {
 				m.focusIndex--
@@ -117,23 +301,72 @@ func (m searchMenu) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			return m, tea.Batch(cmds...)
 		}
-	case []LogEntry:
-		cmd := m.loadEntries(msg)
-		m.list.SetFilteringEnabled(false)
-		m.showResult = true
-		return m, cmd
+	case streamOpened, entriesBatch, cachedEntries, pageError:
+		return m, m.updatePaging(msg)
+	case surroundingEntries:
+		if !m.surrounding.active || msg.id != m.surrounding.id {
+			return m, nil
+		}
+		return m, m.surrounding.load(msg.entries)
+	case surroundingError:
+		if tinyhatchet.IsUnauthorized(msg.err) {
+			return sessionExpired()
+		}
+		if !m.surrounding.active || msg.id != m.surrounding.id {
+			return m, nil
+		}
+		m.surrounding.loading = false
+		m.surrounding.list.Title = m.surrounding.title()
+		return m, m.surrounding.list.NewStatusMessage(errorStyle.Render(msg.err.Error()))
+	case copiedEntry:
+		m.detail.status = focusedStyle.Render("Copied to clipboard")
+		return m, nil
+	case exported:
+		return m, m.list.NewStatusMessage(fmt.Sprintf("Exported %d entries to %s", msg.count, msg.path))
+	case followTick:
+		if !m.follow.active(msg.id) {
+			return m, nil
+		}
+		return m, m.pollEntries
+	case followedEntries:
+		if !m.follow.active(msg.id) {
+			return m, nil
+		}
+		loaded := len(m.entries)
+		cmd := m.appendEntries(msg.entries, true)
+		m.list.Title = m.resultTitle()
+		if msg.full && len(m.entries) > loaded {
+			// more entries are waiting, ask for them right away
+			return m, tea.Batch(cmd, m.pollEntries)
+		}
+		return m, tea.Batch(cmd, followAfter(msg.id))
 	case tea.WindowSizeMsg:
 		width, height = msg.Width, msg.Height
-		top, right, bottom, left := docStyle.GetMargin()
-		m.list.SetSize(msg.Width-left-right, msg.Height-top-bottom)
+		m.layout()
+	case followError:
+		if tinyhatchet.IsUnauthorized(msg.err) {
+			return sessionExpired()
+		}
+		if m.follow.active(msg.id) {
+			return m, tea.Batch(m.showError(msg.err), followAfter(msg.id))
+		}
+		return m, nil
 	case error:
-		log.Println(msg)
+		if tinyhatchet.IsUnauthorized(msg) {
+			return sessionExpired()
+		}
+		return m, m.showError(msg)
 	}
 	if m.showResult {
 		var cmd tea.Cmd
 
 		m.list, cmd = m.list.Update(msg)
-		return m, cmd
+		if m.surrounding.active {
+			var surroundingCmd tea.Cmd
+			m.surrounding.list, surroundingCmd = m.surrounding.list.Update(msg)
+			cmd = tea.Batch(cmd, surroundingCmd)
+		}
+		return m, tea.Batch(cmd, m.nextPage())
 	}
 
 	return m, m.updateInputs(msg)
@@ -149,54 +382,128 @@ func (s *searchMenu) updateInputs(msg tea.Msg) tea.Cmd {
 	return tea.Batch(cmds...)
 }
 
-func (s *searchMenu) getEntries() tea.Msg {
-	//TODO: add input validation
-	u, err := url.Parse(appConfig.BuildURL("/client/get_entries"))
-	if err != nil {
-		return err
+// query builds the query from the inputs, resolving the time range.
+// It reports false and sets the input errors when an input is invalid.
+func (s *searchMenu) query() (tinyhatchet.Query, bool) {
+	query := s.savedSearch().query()
+	for i := range s.inputErrors {
+		s.inputErrors[i] = nil
 	}
+	s.inputErrors[searchStart], s.inputErrors[searchEnd] = resolveRange(&query, time.Now().In(displayZone))
+	_, s.inputErrors[searchLevel] = parseLevel(s.inputs[searchLevel].Value())
+	for _, err := range s.inputErrors {
+		if err != nil {
+			return query, false
+		}
+	}
+	return query, true
+}
 
-	start := s.inputs[0].Value()
-	end := s.inputs[1].Value()
-	tags := s.inputs[2].Value()
+func (s *searchMenu) loadEntries(entries []tinyhatchet.LogEntry) tea.Cmd {
+	s.follow.reset()
+	s.entries = make([]tinyhatchet.LogEntry, 0, len(entries))
+	for _, entry := range entries {
+		s.follow.see(entry)
+		s.entries = append(s.entries, entry)
+	}
+	return s.showEntries()
+}
 
-	q := u.Query()
-	if start != "" {
-		q.Add("start", start)
+// showEntries fills the list with the loaded entries that pass the filter,
+// keeping the selected entry selected when it is still shown
+func (s *searchMenu) showEntries() tea.Cmd {
+	var selectedKey string
+	if selected, ok := s.list.SelectedItem().(item); ok {
+		selectedKey = entryKey(selected.LogEntry)
 	}
-	if end != "" {
-		q.Add("end", end)
+	items := make([]list.Item, 0, len(s.entries))
+	index := 0
+	s.facets.count(s.entries)
+	for _, entry := range s.entries {
+		i := newItem(entry)
+		if !i.level.atLeast(s.minLevel) || !s.filter.match(entry) || !s.facets.match(entry) {
+			continue
+		}
+		items = append(items, i)
 	}
-	if tags != "" {
-		q.Add("tags", tags)
+	sortItems(items, s.sort)
+	for j, listItem := range items {
+		if selectedKey != "" && entryKey(listItem.(item).LogEntry) == selectedKey {
+			index = j
+		}
 	}
-	u.RawQuery = q.Encode()
+	cmd := s.list.SetItems(items)
+	s.list.Select(index)
+	s.list.Title = s.resultTitle()
+	s.chart(items)
+	return cmd
+}
 
-	res, err := httpClient.Get(u.String())
-	if err != nil {
-		return err
+// submit runs the search in the inputs and records it in the history.
+// Tags included or excluded in the tag panel belong to the previous results
+// and are cleared, zooming keeps them as it goes through run.
+func (s *searchMenu) submit() tea.Cmd {
+	cmd := s.run()
+	if cmd == nil {
+		return nil
 	}
-	defer func() {
-		res.Body.Close()
-	}()
-	body, err := io.ReadAll(res.Body)
-	if err != nil {
-		return err
+	s.facets.states = nil
+	return tea.Batch(cmd, s.recordHistory())
+}
+
+// run runs the search in the inputs, nil when they are not valid
+func (s *searchMenu) run() tea.Cmd {
+	query, ok := s.query()
+	if !ok {
+		return nil
 	}
-	logEntries := make([]LogEntry, 0)
-	err = json.Unmarshal(body, &logEntries)
-	if err != nil {
-		return err
+	query.Order = s.sort.order()
+	if query.Order == "" {
+		// sorting by tag or length keeps the order the loaded entries were asked in
+		query.Order = s.lastQuery.Order
 	}
-	return logEntries
+	s.lastQuery = query
+	s.minLevel, _ = parseLevel(s.inputs[searchLevel].Value())
+	return s.startSearch(query)
 }
 
-func (s *searchMenu) loadEntries(entries []LogEntry) tea.Cmd {
-	items := make([]list.Item, 0, len(entries))
-	for _, entry := range entries {
-		items = append(items, item{entry})
+// layout sizes the result list to the window, leaving room for the panels that are open
+func (s *searchMenu) layout() {
+	top, right, bottom, left := docStyle.GetMargin()
+	listHeight := height - top - bottom - histogramHeight
+	if s.export.active {
+		listHeight--
+	}
+	if s.filter.active {
+		listHeight--
+	}
+	if s.save.active {
+		listHeight--
+	}
+	listWidth := width - left - right
+	if s.facets.visible {
+		listWidth -= facetWidth
+	}
+	s.list.SetSize(listWidth, listHeight)
+	if s.detail.active {
+		s.detail.setSize(width-left-right, height-top-bottom)
+	}
+	if s.surrounding.active {
+		s.surrounding.list.SetSize(width-left-right, height-top-bottom)
 	}
-	return s.list.SetItems(items)
+}
+
+// showError renders err in the status bar of the result view, or under the form when it is shown
+func (s *searchMenu) showError(err error) tea.Cmd {
+	if s.detail.active {
+		s.detail.status = errorStyle.Render(err.Error())
+		return nil
+	}
+	if s.showResult {
+		return s.list.NewStatusMessage(errorStyle.Render(err.Error()))
+	}
+	s.Error = err
+	return nil
 }
 
 func (s searchMenu) View() string {
@@ -208,8 +515,26 @@ func (s searchMenu) View() string {
 }
 
 func (s searchMenu) resultView() string {
-
-	return docStyle.Render(s.list.View())
+	if s.detail.active {
+		return docStyle.Render(s.detail.View())
+	}
+	if s.surrounding.active {
+		return docStyle.Render(s.surrounding.list.View())
+	}
+	view := s.timeline.View() + s.list.View()
+	if s.facets.visible {
+		view = s.timeline.View() + lipgloss.JoinHorizontal(lipgloss.Top, s.list.View(), s.facets.View(s.list.Height()))
+	}
+	if s.export.active {
+		return docStyle.Render(view + "\n" + s.export.View())
+	}
+	if s.filter.active {
+		return docStyle.Render(view + "\n" + s.filter.View())
+	}
+	if s.save.active {
+		return docStyle.Render(view + "\n" + s.save.View())
+	}
+	return docStyle.Render(view)
 }
 
 func (s searchMenu) menuView() string {
@@ -217,16 +542,39 @@ func (s searchMenu) menuView() string {
 
 	for i := range s.inputs {
 		b.WriteString(s.inputs[i].View())
+		if s.inputErrors[i] != nil {
+			fmt.Fprintf(&b, "\n  %s", errorStyle.Render(s.inputErrors[i].Error()))
+		}
 		if i < len(s.inputs)-1 {
 			b.WriteRune('\n')
 		}
 	}
 
+	fmt.Fprintf(&b, "\n\n%s", blurredStyle.Render("Times without a zone are read in "+zoneName(displayZone)))
+
 	buttonStyle := &blurredStyle
 	if s.focusIndex == len(s.inputs) {
 		buttonStyle = &focusedStyle
 	}
 	fmt.Fprintf(&b, "\n\n%s\n\n", buttonStyle.Render(submitButtonText))
 
+	switch {
+	case s.history.finder.active:
+		b.WriteString(s.history.finder.View())
+	case s.save.active:
+		fmt.Fprintf(&b, "%s\n", s.save.View())
+	case s.save.status != "":
+		fmt.Fprintf(&b, "%s\n", s.save.status)
+	default:
+		fmt.Fprintf(&b, "%s\n", blurredStyle.Render("ctrl+s to save this search, up or ctrl+r to recall an earlier one"))
+	}
+
+	if s.pages.loading {
+		fmt.Fprintf(&b, "%s\n", blurredStyle.Render(fmt.Sprintf("Searching… %d entries received, ctrl+x to cancel", s.pages.received)))
+	}
+	if s.Error != nil {
+		fmt.Fprintf(&b, "%s\n", errorStyle.Render(strings.Title(s.Error.Error())))
+	}
+
 	return b.String()
 }
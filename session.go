@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/TinyHatchet/client/tinyhatchet"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+var sessionPath string
+
+type sessionCookie struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type loggedOut struct{}
+
+var errSessionExpired = errors.New("your session has expired, please log in again")
+
+// loadSession restores the cookies saved by a previous run into c
+// and reports whether the server still accepts them
+func loadSession(c *tinyhatchet.Client, path string) (bool, error) {
+	body, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("read session file: %w", err)
+	}
+	saved := []sessionCookie{}
+	err = json.Unmarshal(body, &saved)
+	if err != nil {
+		return false, fmt.Errorf("unmarshal session: %w", err)
+	}
+	if len(saved) == 0 {
+		return false, nil
+	}
+
+	u, err := url.Parse(c.ServerURL)
+	if err != nil {
+		return false, fmt.Errorf("parse server url: %w", err)
+	}
+	cookies := make([]*http.Cookie, 0, len(saved))
+	for _, cookie := range saved {
+		cookies = append(cookies, &http.Cookie{Name: cookie.Name, Value: cookie.Value, Path: "/"})
+	}
+	c.HTTPClient.Jar.SetCookies(u, cookies)
+
+	return validateSession(c), nil
+}
+
+// validateSession lists the API tokens, which only succeeds with a valid session.
+// A server that cannot be reached keeps the session, searches then use the cache.
+func validateSession(c *tinyhatchet.Client) bool {
+	if offlineMode {
+		return true
+	}
+	_, err := c.ListTokens(context.Background())
+	return err == nil || tinyhatchet.IsUnreachable(err)
+}
+
+// saveSession writes the cookies for the server to path, removing the file when there are none
+func saveSession(path string) error {
+	u, err := url.Parse(client.ServerURL)
+	if err != nil {
+		return fmt.Errorf("parse server url: %w", err)
+	}
+	cookies := client.HTTPClient.Jar.Cookies(u)
+	if len(cookies) == 0 {
+		return clearSession(path)
+	}
+
+	saved := make([]sessionCookie, 0, len(cookies))
+	for _, c := range cookies {
+		saved = append(saved, sessionCookie{Name: c.Name, Value: c.Value})
+	}
+	body, err := json.Marshal(saved)
+	if err != nil {
+		return fmt.Errorf("marshal session: %w", err)
+	}
+	err = ioutil.WriteFile(path, body, 0600)
+	if err != nil {
+		return fmt.Errorf("write session file: %w", err)
+	}
+	return nil
+}
+
+func clearSession(path string) error {
+	err := os.Remove(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("remove session file: %w", err)
+	}
+	return nil
+}
+
+// logout ends the session on the server, then forgets it locally even if the server
+// could not be reached, along with the entries cached for the profile
+func logout() tea.Msg {
+	_ = client.Logout(context.Background())
+	err := clearSession(sessionPath)
+	if err != nil {
+		return err
+	}
+	err = clearCache(client.ServerURL, appConfig.CurrentProfile)
+	if err != nil {
+		return err
+	}
+	return loggedOut{}
+}
+
+// sessionExpired forgets the rejected session and sends the user back to the login page
+func sessionExpired() (tea.Model, tea.Cmd) {
+	_ = client.ResetSession()
+	err := clearSession(sessionPath)
+	if err != nil {
+		log.Println(err)
+	}
+	form := LoginForm()
+	form.Error = errSessionExpired
+	return loginPage{view: loginPageLogin, loginForm: form, confirmForm: ConfirmForm()}, nil
+}
+
+// saveCurrentSession stores the session of the current profile. Nothing is
+// written while an API token is in use so an earlier login is kept.
+func saveCurrentSession() {
+	if client == nil || appToken.IsSet() {
+		return
+	}
+	err := saveSession(sessionPath)
+	if err != nil {
+		log.Println(err)
+	}
+}
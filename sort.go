@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/TinyHatchet/client/tinyhatchet"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// sortMode is the order of the result list
+type sortMode int
+
+const (
+	sortOldest sortMode = iota
+	sortNewest
+	sortTag
+	sortLength
+)
+
+var sortModeNames = []string{"oldest first", "newest first", "by first tag", "longest first"}
+
+func (m sortMode) String() string {
+	return sortModeNames[m]
+}
+
+// next returns the mode after m, or before it when by is negative
+func (m sortMode) next(by int) sortMode {
+	n := len(sortModeNames)
+	return sortMode(((int(m)+by)%n + n) % n)
+}
+
+// order is the order asked of the server. Only the time modes have one,
+// the others sort whatever was loaded.
+func (m sortMode) order() string {
+	switch m {
+	case sortOldest:
+		return tinyhatchet.OrderAscending
+	case sortNewest:
+		return tinyhatchet.OrderDescending
+	}
+	return ""
+}
+
+// parseOrder reads the -order flag of the search command
+func parseOrder(s string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "":
+		return "", nil
+	case "oldest", "asc":
+		return tinyhatchet.OrderAscending, nil
+	case "newest", "desc":
+		return tinyhatchet.OrderDescending, nil
+	}
+	return "", fmt.Errorf("unknown order %q, use oldest or newest", s)
+}
+
+// sortItems sorts the items in place, entries that compare equal keep their loaded order
+func sortItems(items []list.Item, mode sortMode) {
+	less := func(a, b item) bool { return a.Timestamp.Before(b.Timestamp) }
+	switch mode {
+	case sortNewest:
+		less = func(a, b item) bool { return a.Timestamp.After(b.Timestamp) }
+	case sortTag:
+		// entries without tags go last
+		less = func(a, b item) bool {
+			if len(a.Tags) == 0 || len(b.Tags) == 0 {
+				return len(b.Tags) == 0 && len(a.Tags) > 0
+			}
+			return strings.ToLower(a.Tags[0]) < strings.ToLower(b.Tags[0])
+		}
+	case sortLength:
+		less = func(a, b item) bool { return utf8.RuneCountInString(a.Text) > utf8.RuneCountInString(b.Text) }
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return less(items[i].(item), items[j].(item))
+	})
+}
+
+// sortBy shows the results in mode. Switching between oldest and newest first
+// searches again, so the first page holds the entries at that end of the range.
+func (s *searchMenu) sortBy(mode sortMode) tea.Cmd {
+	s.sort = mode
+	status := s.list.NewStatusMessage("Sorted " + mode.String())
+	if order := mode.order(); order != "" && order != s.lastQuery.Order {
+		s.lastQuery.Order = order
+		s.stopFollowing()
+		return tea.Batch(s.startSearch(s.lastQuery), status)
+	}
+	return tea.Batch(s.showEntries(), status)
+}
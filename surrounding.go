@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/TinyHatchet/client/tinyhatchet"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	defaultContextWindow = 2 * time.Minute
+	minContextWindow     = time.Second
+	// contextLimit caps the entries fetched around an entry so a busy window stays responsive
+	contextLimit = 1000
+)
+
+type contextKeyMap struct {
+	Back   key.Binding
+	Open   key.Binding
+	Wider  key.Binding
+	Narrow key.Binding
+}
+
+var contextKeys = contextKeyMap{
+	Back:   key.NewBinding(key.WithKeys("esc", "backspace"), key.WithHelp("esc", "back")),
+	Open:   key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "details")),
+	Wider:  key.NewBinding(key.WithKeys("+", "="), key.WithHelp("+", "wider")),
+	Narrow: key.NewBinding(key.WithKeys("-"), key.WithHelp("-", "narrower")),
+}
+
+func (k contextKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Back, k.Open, k.Wider, k.Narrow}
+}
+
+// surroundingView shows every entry within window of the anchor entry, whatever its tags.
+// Every query gets a new id so that answers to an earlier window are dropped.
+type surroundingView struct {
+	active  bool
+	id      int
+	anchor  tinyhatchet.LogEntry
+	window  time.Duration
+	loading bool
+	list    list.Model
+}
+
+type surroundingEntries struct {
+	id      int
+	entries []tinyhatchet.LogEntry
+}
+
+type surroundingError struct {
+	id  int
+	err error
+}
+
+// contextWindow reads the window from the config, falling back to the default
+func contextWindow() time.Duration {
+	if appConfig.ContextWindow == "" {
+		return defaultContextWindow
+	}
+	window, err := time.ParseDuration(appConfig.ContextWindow)
+	if err != nil || window < minContextWindow {
+		log.Printf("invalid context window %q, using %s", appConfig.ContextWindow, defaultContextWindow)
+		return defaultContextWindow
+	}
+	return window
+}
+
+// showSurrounding opens the surrounding view for the selected result
+func (s *searchMenu) showSurrounding() tea.Cmd {
+	selected, ok := s.list.SelectedItem().(item)
+	if !ok {
+		return nil
+	}
+	v := &s.surrounding
+	v.active = true
+	v.anchor = selected.LogEntry
+	v.window = contextWindow()
+	top, right, bottom, left := docStyle.GetMargin()
+	v.list = list.NewModel(nil, newEntryDelegate(), width-left-right, height-top-bottom)
+	v.list.SetFilteringEnabled(false)
+	v.list.AdditionalShortHelpKeys = contextKeys.ShortHelp
+	v.list.StatusMessageLifetime = statusMessageLifetime
+	return v.fetch()
+}
+
+func (v *surroundingView) fetch() tea.Cmd {
+	v.id++
+	v.loading = true
+	v.list.Title = v.title()
+	id := v.id
+	query := tinyhatchet.Query{
+		Start: v.anchor.Timestamp.Add(-v.window).UTC().Format(time.RFC3339Nano),
+		End:   v.anchor.Timestamp.Add(v.window).UTC().Format(time.RFC3339Nano),
+		Limit: contextLimit,
+	}
+	return func() tea.Msg {
+		entries, err := client.SearchEntries(context.Background(), query)
+		if err != nil {
+			return surroundingError{id: id, err: err}
+		}
+		return surroundingEntries{id: id, entries: entries}
+	}
+}
+
+func (v surroundingView) title() string {
+	title := fmt.Sprintf("Around %s (±%s)", displayTime(v.anchor.Timestamp), v.window)
+	if v.loading {
+		return title + ", loading…"
+	}
+	title += fmt.Sprintf(", %d entries", len(v.list.Items()))
+	if len(v.list.Items()) == contextLimit {
+		title += ", narrow the window to see them all"
+	}
+	return title
+}
+
+// load shows the entries, selecting and highlighting the anchor
+func (v *surroundingView) load(entries []tinyhatchet.LogEntry) tea.Cmd {
+	anchorKey := entryKey(v.anchor)
+	items := make([]list.Item, 0, len(entries)+1)
+	index := -1
+	for _, entry := range entries {
+		i := newItem(entry)
+		if index < 0 && entryKey(entry) == anchorKey {
+			i.highlight = true
+			index = len(items)
+		}
+		items = append(items, i)
+	}
+	if index < 0 {
+		// the window did not include the entry itself, show it in its place
+		i := newItem(v.anchor)
+		i.highlight = true
+		index = len(items)
+		for j, listItem := range items {
+			if listItem.(item).Timestamp.After(v.anchor.Timestamp) {
+				index = j
+				break
+			}
+		}
+		items = append(items[:index], append([]list.Item{i}, items[index:]...)...)
+	}
+	v.loading = false
+	cmd := v.list.SetItems(items)
+	v.list.Select(index)
+	v.list.Title = v.title()
+	return cmd
+}
+
+// updateSurrounding handles input while the surrounding view is open
+func (s *searchMenu) updateSurrounding(msg tea.KeyMsg) tea.Cmd {
+	v := &s.surrounding
+	switch {
+	case key.Matches(msg, contextKeys.Back):
+		v.active = false
+		v.id++
+		return nil
+	case key.Matches(msg, contextKeys.Open):
+		selected, ok := v.list.SelectedItem().(item)
+		if !ok {
+			return nil
+		}
+		top, right, bottom, left := docStyle.GetMargin()
+		s.detail = newEntryDetail(selected.LogEntry, width-left-right, height-top-bottom)
+		return nil
+	case key.Matches(msg, contextKeys.Wider):
+		v.window *= 2
+		return v.fetch()
+	case key.Matches(msg, contextKeys.Narrow):
+		if v.window/2 < minContextWindow {
+			return nil
+		}
+		v.window /= 2
+		return v.fetch()
+	}
+	var cmd tea.Cmd
+	v.list, cmd = v.list.Update(msg)
+	return cmd
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/TinyHatchet/client/tinyhatchet"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/reflow/truncate"
+)
+
+// formatTemplate writes every entry through a text/template given with -format
+const formatTemplate outputFormat = "template"
+
+// colorNames are the names accepted by the color template function besides
+// ANSI numbers like 205 and hex colors like #FF5F5F
+var colorNames = map[string]string{
+	"black":   "0",
+	"red":     "1",
+	"green":   "2",
+	"yellow":  "3",
+	"blue":    "4",
+	"magenta": "5",
+	"cyan":    "6",
+	"white":   "7",
+	"gray":    "8",
+	"grey":    "8",
+}
+
+// timeLayouts are the names accepted by the time template function besides Go layouts
+var timeLayouts = map[string]string{
+	"rfc3339":     time.RFC3339,
+	"rfc3339nano": time.RFC3339Nano,
+	"kitchen":     time.Kitchen,
+	"stamp":       time.StampMilli,
+	"date":        "2006-01-02",
+	"clock":       "15:04:05",
+}
+
+// templateFuncs are the helpers available to -format templates, for example
+// {{time "clock" .Timestamp}} {{color "red" (upper (level .))}} {{truncate 80 .Text}}
+var templateFuncs = template.FuncMap{
+	"join": func(elems []string, sep string) string {
+		return strings.Join(elems, sep)
+	},
+	"time": func(layout string, t time.Time) string {
+		if named, ok := timeLayouts[strings.ToLower(layout)]; ok {
+			layout = named
+		}
+		return t.In(displayZone).Format(layout)
+	},
+	"unix": func(t time.Time) int64 {
+		return t.Unix()
+	},
+	"truncate": func(width int, s string) string {
+		return truncate.StringWithTail(s, uint(width), "…")
+	},
+	"color": func(color, s string) string {
+		if named, ok := colorNames[strings.ToLower(color)]; ok {
+			color = named
+		}
+		return lipgloss.NewStyle().Foreground(lipgloss.Color(color)).Render(s)
+	},
+	"bold": func(s string) string {
+		return lipgloss.NewStyle().Bold(true).Render(s)
+	},
+	"level": func(entry tinyhatchet.LogEntry) string {
+		return entryLevel(entry).String()
+	},
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+}
+
+func parseEntryTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("format").Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("format: %w", err)
+	}
+	return tmpl, nil
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/TinyHatchet/client/tinyhatchet"
+)
+
+// localLayouts are the date and time formats read in the zone of now
+var localLayouts = []string{
+	"2006-01-02",
+	"2006-01-02 15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02T15:04:05",
+}
+
+var clockLayouts = []string{"15:04", "15:04:05"}
+
+var (
+	relativePattern = regexp.MustCompile(`^(?:-\s*(\d+)\s*([a-z]+)|(\d+)\s*([a-z]+)\s+ago)$`)
+	epochPattern    = regexp.MustCompile(`^\d+$`)
+)
+
+var timeUnits = map[string]time.Duration{
+	"s": time.Second, "sec": time.Second, "secs": time.Second, "second": time.Second, "seconds": time.Second,
+	"m": time.Minute, "min": time.Minute, "mins": time.Minute, "minute": time.Minute, "minutes": time.Minute,
+	"h": time.Hour, "hr": time.Hour, "hrs": time.Hour, "hour": time.Hour, "hours": time.Hour,
+	"d": 24 * time.Hour, "day": 24 * time.Hour, "days": 24 * time.Hour,
+	"w": 7 * 24 * time.Hour, "week": 7 * 24 * time.Hour, "weeks": 7 * 24 * time.Hour,
+}
+
+// parseTime reads the times accepted by the search form and the CLI, relative to now
+// and in now's time zone: RFC3339, -15m, 1h ago, now, today, yesterday 09:00, 09:00,
+// Unix epochs in seconds or milliseconds and local dates like 2021-06-01 11:22
+func parseTime(input string, now time.Time) (time.Time, error) {
+	s := strings.ToLower(strings.TrimSpace(input))
+	loc := now.Location()
+
+	if t, err := time.Parse(time.RFC3339Nano, strings.ToUpper(s)); err == nil {
+		return t, nil
+	}
+	if s == "now" {
+		return now, nil
+	}
+	if m := relativePattern.FindStringSubmatch(s); m != nil {
+		amount, unit := m[1], m[2]
+		if amount == "" {
+			amount, unit = m[3], m[4]
+		}
+		d, ok := timeUnits[unit]
+		if !ok {
+			return time.Time{}, fmt.Errorf("unknown unit %q, use s, m, h, d or w", unit)
+		}
+		n, err := strconv.ParseInt(amount, 10, 64)
+		if err != nil || n > math.MaxInt64/int64(d) {
+			return time.Time{}, fmt.Errorf("invalid amount %q", amount)
+		}
+		return now.Add(-time.Duration(n) * d).Truncate(time.Second), nil
+	}
+	if epochPattern.MatchString(s) {
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid epoch %q", s)
+		}
+		if len(s) > 11 {
+			if n > math.MaxInt64/int64(time.Millisecond) {
+				return time.Time{}, fmt.Errorf("invalid epoch %q", s)
+			}
+			return time.Unix(0, n*int64(time.Millisecond)).In(loc), nil
+		}
+		return time.Unix(n, 0).In(loc), nil
+	}
+	for _, day := range []string{"today", "yesterday"} {
+		if s != day && !strings.HasPrefix(s, day+" ") {
+			continue
+		}
+		y, mo, d := now.Date()
+		date := time.Date(y, mo, d, 0, 0, 0, 0, loc)
+		if day == "yesterday" {
+			date = date.AddDate(0, 0, -1)
+		}
+		clock := strings.TrimSpace(strings.TrimPrefix(s, day))
+		if clock == "" {
+			return date, nil
+		}
+		return atClock(date, clock)
+	}
+	for _, layout := range localLayouts {
+		if t, err := time.ParseInLocation(layout, strings.ToUpper(s), loc); err == nil {
+			return t, nil
+		}
+	}
+	y, mo, d := now.Date()
+	if t, err := atClock(time.Date(y, mo, d, 0, 0, 0, 0, loc), s); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("unrecognised time %q, try -15m, 1h ago, yesterday 09:00 or 2021-06-01 11:22", strings.TrimSpace(input))
+}
+
+// atClock sets the time of day on date from 15:04 or 15:04:05
+func atClock(date time.Time, clock string) (time.Time, error) {
+	for _, layout := range clockLayouts {
+		t, err := time.Parse(layout, clock)
+		if err == nil {
+			y, mo, d := date.Date()
+			return time.Date(y, mo, d, t.Hour(), t.Minute(), t.Second(), 0, date.Location()), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time of day %q, use 15:04 or 15:04:05", clock)
+}
+
+// resolveTime converts a time input to the RFC3339 form sent to the server, empty stays empty
+func resolveTime(input string, now time.Time) (string, error) {
+	if strings.TrimSpace(input) == "" {
+		return "", nil
+	}
+	t, err := parseTime(input, now)
+	if err != nil {
+		return "", err
+	}
+	return t.UTC().Format(time.RFC3339Nano), nil
+}
+
+// resolveRange replaces the start and end of the query with their RFC3339 form,
+// both evaluated at now so that relative times move with every run
+func resolveRange(query *tinyhatchet.Query, now time.Time) (startErr, endErr error) {
+	query.Start, startErr = resolveTime(query.Start, now)
+	query.End, endErr = resolveTime(query.End, now)
+	if startErr != nil || endErr != nil || query.Start == "" || query.End == "" {
+		return startErr, endErr
+	}
+	start, _ := time.Parse(time.RFC3339Nano, query.Start)
+	end, _ := time.Parse(time.RFC3339Nano, query.End)
+	if end.Before(start) {
+		endErr = fmt.Errorf("end is before start")
+	}
+	return startErr, endErr
+}
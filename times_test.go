@@ -0,0 +1,115 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/TinyHatchet/client/tinyhatchet"
+)
+
+func TestParseTime(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Skip(err)
+	}
+	now := time.Date(2021, 6, 15, 14, 30, 45, 500, paris)
+	tests := []struct {
+		input string
+		want  time.Time
+	}{
+		{"2021-06-01T11:22:33Z", time.Date(2021, 6, 1, 11, 22, 33, 0, time.UTC)},
+		{"2021-06-01t11:22:33.5z", time.Date(2021, 6, 1, 11, 22, 33, 500000000, time.UTC)},
+		{"2021-06-01T11:22:33+02:00", time.Date(2021, 6, 1, 9, 22, 33, 0, time.UTC)},
+		{"now", now},
+		{" NOW ", now},
+		{"-15m", time.Date(2021, 6, 15, 14, 15, 45, 0, paris)},
+		{"- 2 hours", time.Date(2021, 6, 15, 12, 30, 45, 0, paris)},
+		{"1h ago", time.Date(2021, 6, 15, 13, 30, 45, 0, paris)},
+		{"3 days ago", time.Date(2021, 6, 12, 14, 30, 45, 0, paris)},
+		{"-1w", time.Date(2021, 6, 8, 14, 30, 45, 0, paris)},
+		{"30s ago", time.Date(2021, 6, 15, 14, 30, 15, 0, paris)},
+		{"1622546553", time.Date(2021, 6, 1, 11, 22, 33, 0, time.UTC)},
+		{"1622546553250", time.Date(2021, 6, 1, 11, 22, 33, 250000000, time.UTC)},
+		{"today", time.Date(2021, 6, 15, 0, 0, 0, 0, paris)},
+		{"today 09:00", time.Date(2021, 6, 15, 9, 0, 0, 0, paris)},
+		{"yesterday", time.Date(2021, 6, 14, 0, 0, 0, 0, paris)},
+		{"yesterday 23:59:30", time.Date(2021, 6, 14, 23, 59, 30, 0, paris)},
+		{"09:15", time.Date(2021, 6, 15, 9, 15, 0, 0, paris)},
+		{"09:15:20", time.Date(2021, 6, 15, 9, 15, 20, 0, paris)},
+		{"2021-06-01", time.Date(2021, 6, 1, 0, 0, 0, 0, paris)},
+		{"2021-06-01 11:22", time.Date(2021, 6, 1, 11, 22, 0, 0, paris)},
+		{"2021-06-01 11:22:33", time.Date(2021, 6, 1, 11, 22, 33, 0, paris)},
+		{"2021-06-01T11:22", time.Date(2021, 6, 1, 11, 22, 0, 0, paris)},
+		{"2021-06-01t11:22:33", time.Date(2021, 6, 1, 11, 22, 33, 0, paris)},
+	}
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			got, err := parseTime(test.input, now)
+			if err != nil {
+				t.Fatalf("parseTime: %v", err)
+			}
+			if !got.Equal(test.want) {
+				t.Errorf("parseTime(%q) = %v, want %v", test.input, got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseTimeErrors(t *testing.T) {
+	now := time.Date(2021, 6, 15, 14, 30, 0, 0, time.UTC)
+	tests := []string{
+		"",
+		"soon",
+		"-15",
+		"-15 fortnights",
+		"3 parsecs ago",
+		"15m",
+		"today 25:00",
+		"yesterday noon",
+		"2021-13-01",
+		"2021-06-01 11",
+		"24:00",
+		"99999999999999999999",
+		"99999999999999999",
+		"-99999999999999w",
+		"9223372036854775807s ago",
+	}
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			got, err := parseTime(input, now)
+			if err == nil {
+				t.Errorf("parseTime(%q) = %v, want an error", input, got)
+			}
+		})
+	}
+}
+
+func TestResolveRange(t *testing.T) {
+	now := time.Date(2021, 6, 15, 14, 30, 0, 0, time.UTC)
+	tests := []struct {
+		start, end         string
+		wantStart, wantEnd string
+		startErr, endErr   bool
+	}{
+		{start: "", end: "", wantStart: "", wantEnd: ""},
+		{start: "-1h", end: "now", wantStart: "2021-06-15T13:30:00Z", wantEnd: "2021-06-15T14:30:00Z"},
+		{start: "now", end: "-1h", endErr: true},
+		{start: "later", end: "now", startErr: true},
+		{start: "-1h", end: "later", endErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.start+" to "+test.end, func(t *testing.T) {
+			query := tinyhatchet.Query{Start: test.start, End: test.end}
+			startErr, endErr := resolveRange(&query, now)
+			if (startErr != nil) != test.startErr || (endErr != nil) != test.endErr {
+				t.Fatalf("errors %v, %v", startErr, endErr)
+			}
+			if test.startErr || test.endErr {
+				return
+			}
+			if query.Start != test.wantStart || query.End != test.wantEnd {
+				t.Errorf("range %q to %q, want %q to %q", query.Start, query.End, test.wantStart, test.wantEnd)
+			}
+		})
+	}
+}
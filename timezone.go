@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// displayZone is the time zone results are shown in and search inputs are read in.
+// It starts as zone, set from the config or -timezone, and the result view can toggle it.
+var (
+	zone        = time.Local
+	displayZone = time.Local
+)
+
+// loadZone reads a time zone setting: local, UTC or an IANA name like Europe/Paris
+func loadZone(name string) (*time.Location, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "local":
+		return time.Local, nil
+	case "utc", "z":
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(strings.TrimSpace(name))
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone %q, use local, UTC or a name like Europe/Paris", name)
+	}
+	return loc, nil
+}
+
+// setZone makes the named zone the one times are shown and read in
+func setZone(name string) error {
+	loc, err := loadZone(name)
+	if err != nil {
+		return err
+	}
+	zone, displayZone = loc, loc
+	return nil
+}
+
+// toggleZone switches between the configured zone and UTC,
+// or between UTC and local when UTC is the configured zone
+func toggleZone() {
+	switch {
+	case displayZone != zone:
+		displayZone = zone
+	case zone == time.UTC:
+		displayZone = time.Local
+	default:
+		displayZone = time.UTC
+	}
+}
+
+// displayTime formats t in the display zone
+func displayTime(t time.Time) string {
+	return t.In(displayZone).Format(time.RFC3339)
+}
+
+// zoneName names the zone with its current abbreviation, like Local (CEST)
+func zoneName(loc *time.Location) string {
+	abbr, _ := time.Now().In(loc).Zone()
+	if abbr == loc.String() {
+		return abbr
+	}
+	return fmt.Sprintf("%s (%s)", loc, abbr)
+}
@@ -0,0 +1,18 @@
+package tinyhatchet
+
+import (
+	"context"
+	"net/http"
+)
+
+type changeEmailCommand struct {
+	Email string `json:"email"`
+}
+
+func (c *Client) UpdateEmail(ctx context.Context, email string) error {
+	req, err := c.newRequest(ctx, http.MethodPost, "/account/change_email", nil, changeEmailCommand{Email: email})
+	if err != nil {
+		return err
+	}
+	return c.do(req, nil)
+}
@@ -0,0 +1,66 @@
+package tinyhatchet
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+type APIResponse struct {
+	Status  string      `json:"status"`
+	Errors  Errors      `json:"errors"`
+	Error   StringError `json:"error"`
+	Message string
+}
+type StringError string
+
+func (s StringError) Error() string {
+	return string(s)
+}
+
+// Errors maps a form field to the problems the server found with it
+type Errors map[string][]string
+
+func (e Errors) String() string {
+	fields := make([]string, 0, len(e))
+	for field := range e {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	parts := make([]string, 0, len(fields))
+	for _, field := range fields {
+		parts = append(parts, fmt.Sprintf("%s: %s", field, strings.Join(e[field], ", ")))
+	}
+	return strings.Join(parts, "; ")
+}
+
+const (
+	StatusSuccess string = "success"
+	StatusFailure string = "failure"
+)
+
+// ParseAPIResponse unmarshals the response and closes the reader
+func ParseAPIResponse(httpResponse *http.Response) (*APIResponse, error) {
+	defer httpResponse.Body.Close()
+	decoder := json.NewDecoder(httpResponse.Body)
+	response := APIResponse{}
+	err := decoder.Decode(&response)
+	if err != nil {
+		return nil, fmt.Errorf("parse api response: %w", err)
+	}
+	return &response, nil
+}
+
+// result turns the response of an auth endpoint into an error, nil on success
+func (response *APIResponse) result(statusCode int) error {
+	if response.Message == MessageVerificationRequired || response.Error == MessageVerificationRequired {
+		return ErrVerificationRequired
+	}
+	if response.Status == StatusSuccess {
+		return nil
+	}
+	return newAPIError(statusCode, response)
+}
@@ -0,0 +1,88 @@
+package tinyhatchet
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+)
+
+const MessageVerificationRequired = "verification required"
+
+var (
+	// ErrVerificationRequired is returned by Login and Register until the
+	// account has been confirmed with the code sent by email
+	ErrVerificationRequired = errors.New(MessageVerificationRequired)
+	ErrNoCredentials        = errors.New("please enter credentials")
+)
+
+type credentials struct {
+	Email           string `json:"email"`
+	Password        string `json:"password"`
+	ConfirmPassword string `json:"confirm_password,omitempty"`
+}
+
+// Login starts a session. The session cookie is kept in the client's cookie jar.
+func (c *Client) Login(ctx context.Context, email, password string) error {
+	if email == "" || password == "" {
+		return ErrNoCredentials
+	}
+	return c.authenticate(ctx, "/auth/login", credentials{Email: email, Password: password})
+}
+
+// Register creates an account, which has to be confirmed before it can be used
+func (c *Client) Register(ctx context.Context, email, password string) error {
+	if email == "" || password == "" {
+		return ErrNoCredentials
+	}
+	return c.authenticate(ctx, "/auth/register", credentials{Email: email, Password: password, ConfirmPassword: password})
+}
+
+func (c *Client) authenticate(ctx context.Context, path string, cmd credentials) error {
+	req, err := c.newRequest(ctx, http.MethodPost, path, nil, cmd)
+	if err != nil {
+		return err
+	}
+	return c.doAuth(req)
+}
+
+// Confirm verifies the account with the code from the confirmation email
+func (c *Client) Confirm(ctx context.Context, code string) error {
+	req, err := c.newRequest(ctx, http.MethodGet, "/auth/confirm", url.Values{"cnf": {code}}, nil)
+	if err != nil {
+		return err
+	}
+	return c.doAuth(req)
+}
+
+// Logout ends the session on the server and drops it from the cookie jar
+func (c *Client) Logout(ctx context.Context) error {
+	req, err := c.newRequest(ctx, http.MethodDelete, "/auth/logout", nil, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.HTTPClient.Do(req)
+	if err == nil {
+		resp.Body.Close()
+	}
+	resetErr := c.ResetSession()
+	if err != nil {
+		return err
+	}
+	return resetErr
+}
+
+func (c *Client) doAuth(req *http.Request) error {
+	httpResponse, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	response, err := ParseAPIResponse(httpResponse)
+	if err != nil {
+		if httpResponse.StatusCode != http.StatusOK {
+			return newAPIError(httpResponse.StatusCode, nil)
+		}
+		return err
+	}
+	return response.result(httpResponse.StatusCode)
+}
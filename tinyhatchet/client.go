@@ -0,0 +1,145 @@
+// Package tinyhatchet is a client for the TinyHatchet log server API.
+//
+// A Client authenticates either with the session cookie set by Login or,
+// when Token is set, with an API token sent on every request.
+package tinyhatchet
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+const (
+	DefaultServerURL = "https://tinyhatchet.com"
+
+	contentTypeJSON = "application/json"
+)
+
+type Client struct {
+	ServerURL  string
+	HTTPClient *http.Client
+	Token      Token
+}
+
+// NewClient returns a client for the server at serverURL with its own cookie jar
+func NewClient(serverURL string) (*Client, error) {
+	jar, err := newCookieJar()
+	if err != nil {
+		return nil, err
+	}
+	return &Client{
+		ServerURL:  strings.TrimSuffix(serverURL, "/"),
+		HTTPClient: &http.Client{Jar: jar},
+	}, nil
+}
+
+func newCookieJar() (http.CookieJar, error) {
+	jar, err := cookiejar.New(&cookiejar.Options{PublicSuffixList: publicsuffix.List})
+	if err != nil {
+		return nil, fmt.Errorf("create cookie jar: %w", err)
+	}
+	return jar, nil
+}
+
+// URL joins path onto the server URL
+func (c *Client) URL(path string) string {
+	return c.ServerURL + path
+}
+
+// ResetSession drops every cookie, forgetting the session without contacting the server
+func (c *Client) ResetSession() error {
+	jar, err := newCookieJar()
+	if err != nil {
+		return err
+	}
+	c.HTTPClient.Jar = jar
+	return nil
+}
+
+// newRequest builds a request for path, encoding body as JSON when it is not nil
+func (c *Client) newRequest(ctx context.Context, method, path string, query url.Values, body interface{}) (*http.Request, error) {
+	u, err := url.Parse(c.URL(path))
+	if err != nil {
+		return nil, fmt.Errorf("parse url: %w", err)
+	}
+	if query != nil {
+		u.RawQuery = query.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		buf := bytes.NewBuffer(nil)
+		err = json.NewEncoder(buf).Encode(body)
+		if err != nil {
+			return nil, fmt.Errorf("encode request: %w", err)
+		}
+		reader = buf
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), reader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentTypeJSON)
+	req.Header.Set("Accept", contentTypeJSON)
+	if c.Token.IsSet() {
+		req.SetBasicAuth(c.Token.ID, c.Token.Secret)
+	}
+	return req, nil
+}
+
+// send sends the request and returns the response if the server accepted it.
+// The caller has to close the body of the returned response.
+func (c *Client) send(req *http.Request) (*http.Response, error) {
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	err = checkStatus(resp)
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	return resp, nil
+}
+
+// do sends the request and decodes a successful JSON response into out when it is not nil.
+// The response body is always closed.
+func (c *Client) do(req *http.Request, out interface{}) error {
+	resp, err := c.send(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if out == nil {
+		return nil
+	}
+	err = json.NewDecoder(resp.Body).Decode(out)
+	if err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
+
+// checkStatus returns an *APIError for any non 200 response, using the
+// details from the body when the server sent a JSON APIResponse
+func checkStatus(resp *http.Response) error {
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+	response, err := ParseAPIResponse(resp)
+	if err != nil {
+		return newAPIError(resp.StatusCode, nil)
+	}
+	return newAPIError(resp.StatusCode, response)
+}
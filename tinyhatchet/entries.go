@@ -0,0 +1,117 @@
+package tinyhatchet
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+var ErrNoToken = errors.New("an API token id and secret are required")
+
+type LogEntry struct {
+	Timestamp time.Time `json:"timestamp"`
+	Text      string    `json:"text"`
+	Tags      []string  `json:"tags"`
+}
+
+// Orders accepted in Query.Order
+const (
+	OrderAscending  = "asc"
+	OrderDescending = "desc"
+)
+
+// Query holds the parameters accepted by /client/get_entries.
+// Start and End are RFC3339 timestamps, Tags is comma separated and
+// Text is a full-text query over the entry text, sent as q.
+// Order asks for the oldest or newest entries first, servers that do not
+// support it return their usual order.
+// A Limit of zero asks for every matching entry at once.
+type Query struct {
+	Start  string
+	End    string
+	Tags   string
+	Text   string
+	Order  string
+	Limit  int
+	Offset int
+}
+
+// Page is one page of search results
+type Page struct {
+	Entries []LogEntry
+	// Next is the query for the following page, nil when this was the last one
+	Next *Query
+}
+
+func (q Query) values() url.Values {
+	v := url.Values{}
+	if q.Start != "" {
+		v.Add("start", q.Start)
+	}
+	if q.End != "" {
+		v.Add("end", q.End)
+	}
+	if q.Tags != "" {
+		v.Add("tags", q.Tags)
+	}
+	if q.Text != "" {
+		v.Add("q", q.Text)
+	}
+	if q.Order != "" {
+		v.Add("order", q.Order)
+	}
+	if q.Limit > 0 {
+		v.Add("limit", strconv.Itoa(q.Limit))
+	}
+	if q.Offset > 0 {
+		v.Add("offset", strconv.Itoa(q.Offset))
+	}
+	return v
+}
+
+// SearchEntries returns every entry matching the query
+func (c *Client) SearchEntries(ctx context.Context, query Query) ([]LogEntry, error) {
+	req, err := c.newRequest(ctx, http.MethodGet, "/client/get_entries", query.values(), nil)
+	if err != nil {
+		return nil, err
+	}
+	logEntries := make([]LogEntry, 0)
+	err = c.do(req, &logEntries)
+	if err != nil {
+		return nil, err
+	}
+	return logEntries, nil
+}
+
+// SearchPage returns the page of entries selected by the query's Limit and Offset.
+// A page shorter than the limit is taken to be the last one. A server that ignores
+// Limit or Offset can send the same full page again, so callers following Next
+// should stop at a page that brings no new entries.
+func (c *Client) SearchPage(ctx context.Context, query Query) (Page, error) {
+	entries, err := c.SearchEntries(ctx, query)
+	if err != nil {
+		return Page{}, err
+	}
+	page := Page{Entries: entries}
+	if query.Limit > 0 && len(entries) == query.Limit {
+		next := query
+		next.Offset += len(entries)
+		page.Next = &next
+	}
+	return page, nil
+}
+
+// SendEntries stores entries on the server. It requires the client to have an API token.
+func (c *Client) SendEntries(ctx context.Context, entries []LogEntry) error {
+	if !c.Token.IsSet() {
+		return ErrNoToken
+	}
+	req, err := c.newRequest(ctx, http.MethodPost, "/client/add_entries", nil, entries)
+	if err != nil {
+		return err
+	}
+	return c.do(req, nil)
+}
@@ -0,0 +1,72 @@
+package tinyhatchet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+)
+
+// APIError is returned for every request the server did not accept.
+// Errors holds the problems found with individual fields, if any.
+type APIError struct {
+	StatusCode int
+	Message    string
+	Errors     Errors
+}
+
+func newAPIError(statusCode int, response *APIResponse) *APIError {
+	apiErr := &APIError{StatusCode: statusCode}
+	if response != nil {
+		apiErr.Errors = response.Errors
+		apiErr.Message = string(response.Error)
+		if apiErr.Message == "" {
+			apiErr.Message = response.Message
+		}
+	}
+	if apiErr.Message == "" {
+		apiErr.Message = http.StatusText(statusCode)
+	}
+	if apiErr.Message == "" {
+		apiErr.Message = fmt.Sprintf("unexpected status %d", statusCode)
+	}
+	return apiErr
+}
+
+func (e *APIError) Error() string {
+	if len(e.Errors) == 0 {
+		return e.Message
+	}
+	return fmt.Sprintf("%s (%s)", e.Message, e.Errors)
+}
+
+// FieldErrors returns the problems the server found with the named field
+func (e *APIError) FieldErrors(field string) []string {
+	return e.Errors[field]
+}
+
+// IsUnauthorized reports whether err is an APIError for a request that was
+// rejected because the session or API token is missing or no longer valid
+func IsUnauthorized(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusUnauthorized
+}
+
+// IsUnreachable reports whether err is a failure to reach the server at all,
+// like a refused connection, an unknown host or a timeout. Other request
+// failures, like a bad certificate or a malformed URL, are not.
+func IsUnreachable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var opErr *net.OpError
+	var dnsErr *net.DNSError
+	if errors.As(err, &opErr) || errors.As(err, &dnsErr) {
+		return true
+	}
+	// url.Error is a net.Error too, only its Timeout says something about the network
+	var urlErr *url.Error
+	return errors.As(err, &urlErr) && urlErr.Timeout()
+}
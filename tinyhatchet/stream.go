@@ -0,0 +1,77 @@
+package tinyhatchet
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// EntryStream decodes the entries of a search response while it is downloaded,
+// so large results can be shown before the whole body has arrived
+type EntryStream struct {
+	body    io.ReadCloser
+	decoder *json.Decoder
+	// empty is set when the server answered null, which stands for no entries
+	empty bool
+}
+
+// StreamEntries starts the search and returns once the server has accepted it.
+// Cancelling ctx aborts the download. The stream has to be closed by the caller.
+func (c *Client) StreamEntries(ctx context.Context, query Query) (*EntryStream, error) {
+	req, err := c.newRequest(ctx, http.MethodGet, "/client/get_entries", query.values(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.send(req)
+	if err != nil {
+		return nil, err
+	}
+
+	stream := &EntryStream{body: resp.Body, decoder: json.NewDecoder(resp.Body)}
+	token, err := stream.decoder.Token()
+	if err != nil {
+		resp.Body.Close()
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+	if token == nil {
+		stream.empty = true
+		return stream, nil
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		resp.Body.Close()
+		return nil, fmt.Errorf("decode response: expected an array of entries, got %v", token)
+	}
+	return stream, nil
+}
+
+// Next decodes up to max entries. It returns io.EOF together with the
+// last entries once the end of the response has been reached.
+func (s *EntryStream) Next(max int) ([]LogEntry, error) {
+	if s.empty {
+		return []LogEntry{}, io.EOF
+	}
+	entries := make([]LogEntry, 0, max)
+	for len(entries) < max && s.decoder.More() {
+		entry := LogEntry{}
+		err := s.decoder.Decode(&entry)
+		if err != nil {
+			return entries, fmt.Errorf("decode entry: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	if len(entries) == max {
+		return entries, nil
+	}
+
+	_, err := s.decoder.Token()
+	if err != nil {
+		return entries, fmt.Errorf("decode response: %w", err)
+	}
+	return entries, io.EOF
+}
+
+func (s *EntryStream) Close() error {
+	return s.body.Close()
+}
@@ -0,0 +1,52 @@
+package tinyhatchet
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// Token is an API token. The secret is only returned by CreateToken.
+type Token struct {
+	ID     string `json:"id"`
+	Secret string `json:"secret"`
+}
+
+func (t Token) IsSet() bool {
+	return t.ID != "" && t.Secret != ""
+}
+
+func (c *Client) CreateToken(ctx context.Context) (Token, error) {
+	req, err := c.newRequest(ctx, http.MethodPost, "/auth/api_token", nil, nil)
+	if err != nil {
+		return Token{}, err
+	}
+	token := Token{}
+	err = c.do(req, &token)
+	return token, err
+}
+
+type listTokensResponse struct {
+	Tokens []Token `json:"tokens"`
+}
+
+func (c *Client) ListTokens(ctx context.Context) ([]Token, error) {
+	req, err := c.newRequest(ctx, http.MethodGet, "/auth/api_token", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	listResponse := listTokensResponse{}
+	err = c.do(req, &listResponse)
+	if err != nil {
+		return nil, err
+	}
+	return listResponse.Tokens, nil
+}
+
+func (c *Client) DeleteToken(ctx context.Context, id string) error {
+	req, err := c.newRequest(ctx, http.MethodDelete, "/auth/api_token", url.Values{"id": {id}}, nil)
+	if err != nil {
+		return err
+	}
+	return c.do(req, nil)
+}